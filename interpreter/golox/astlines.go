@@ -0,0 +1,65 @@
+package golox
+
+// Get the line where the statement starts in the source, 0 if the statement
+// has no token giving its position (e.g. a block)
+func stmtLine(stmt Stmt[any]) int {
+	switch stmt := stmt.(type) {
+	case *Expression[any]:
+		return exprLine(stmt.expression)
+	case *If[any]:
+		return stmt.keyword.line
+	case *Import[any]:
+		return stmt.keyword.line
+	case *Print[any]:
+		return stmt.keyword.line
+	case *Return[any]:
+		return stmt.keyword.line
+	case *Throw[any]:
+		return stmt.keyword.line
+	case *Try[any]:
+		return stmt.keyword.line
+	case *Var[any]:
+		return stmt.name.line
+	case *While[any]:
+		return stmt.keyword.line
+	}
+	return 0
+}
+
+// Get the line where the expression starts in the source, 0 if the expression
+// has no token giving its position (e.g. a literal)
+func exprLine(expr Expr[any]) int {
+	switch expr := expr.(type) {
+	case *Assign[any]:
+		return expr.name.line
+	case *Binary[any]:
+		if line := exprLine(expr.left); line != 0 {
+			return line
+		}
+		return expr.operator.line
+	case *Call[any]:
+		if line := exprLine(expr.callee); line != 0 {
+			return line
+		}
+		return expr.paren.line
+	case *Function[any]:
+		return expr.keyword.line
+	case *Get[any]:
+		if line := exprLine(expr.object); line != 0 {
+			return line
+		}
+		return expr.name.line
+	case *Grouping[any]:
+		return exprLine(expr.expression)
+	case *Logical[any]:
+		if line := exprLine(expr.left); line != 0 {
+			return line
+		}
+		return expr.operator.line
+	case *Unary[any]:
+		return expr.operator.line
+	case *Variable[any]:
+		return expr.name.line
+	}
+	return 0
+}
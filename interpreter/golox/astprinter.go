@@ -104,10 +104,59 @@ func (ap *AstPrinter) visitIfStmt(stmt *If[any]) (any, error) {
 	return builder.String(), nil
 }
 
+func (ap *AstPrinter) visitImportStmt(stmt *Import[any]) (any, error) {
+	if stmt.alias != nil {
+		return fmt.Sprintf("(import %s as %s)", stmt.path.lexeme, stmt.alias.lexeme), nil
+	}
+	names := make([]string, len(stmt.names))
+	for i, name := range stmt.names {
+		names[i] = name.lexeme
+	}
+	return fmt.Sprintf("(from %s import %s)", stmt.path.lexeme, strings.Join(names, " ")), nil
+}
+
 func (ap *AstPrinter) visitPrintStmt(stmt *Print[any]) (any, error) {
 	return ap.parenthesize("print", stmt.expression)
 }
 
+func (ap *AstPrinter) visitReturnStmt(stmt *Return[any]) (any, error) {
+	if stmt.value == nil {
+		return "(return)", nil
+	}
+	return ap.parenthesize("return", stmt.value)
+}
+
+func (ap *AstPrinter) visitThrowStmt(stmt *Throw[any]) (any, error) {
+	return ap.parenthesize("throw", stmt.value)
+}
+
+func (ap *AstPrinter) visitTryStmt(stmt *Try[any]) (any, error) {
+	var builder strings.Builder
+	builder.WriteString("(try ")
+	clauses := []Stmt[any]{stmt.body, stmt.handler, stmt.finalizer}
+	for i, clause := range clauses {
+		if clause == nil {
+			continue
+		}
+		if i == 1 {
+			builder.WriteString(" catch " + stmt.name.lexeme + " ")
+		} else if i == 2 {
+			builder.WriteString(" finally ")
+		}
+		value, err := clause.accept(ap)
+		if err != nil {
+			return nil, err
+		}
+		valueString, ok := value.(string)
+		if !ok {
+			return nil, errors.New("the construction of the AST failed")
+		}
+		builder.WriteString(valueString)
+	}
+	builder.WriteString(")")
+	return builder.String(), nil
+}
+
 func (ap *AstPrinter) visitVarStmt(stmt *Var[any]) (any, error) {
 	if stmt.initializer != nil {
 		return ap.parenthesize("var "+stmt.name.lexeme, stmt.initializer)
@@ -136,6 +185,43 @@ func (ap *AstPrinter) visitCallExpr(expr *Call[any]) (any, error) {
 	return "", nil
 }
 
+func (ap *AstPrinter) visitFunctionExpr(expr *Function[any]) (any, error) {
+	var builder strings.Builder
+	builder.WriteString("(fun (")
+	for i, param := range expr.params {
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+		if expr.defaults[i] == nil {
+			builder.WriteString(param.lexeme)
+			continue
+		}
+		value, err := ap.parenthesize("= "+param.lexeme, expr.defaults[i])
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(value)
+	}
+	if expr.rest != nil {
+		if len(expr.params) > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString("..." + expr.rest.lexeme)
+	}
+	builder.WriteString(") ")
+	body, err := ap.visitBlockStmt(NewBlock(expr.body))
+	if err != nil {
+		return nil, err
+	}
+	builder.WriteString(body.(string))
+	builder.WriteString(")")
+	return builder.String(), nil
+}
+
+func (ap *AstPrinter) visitGetExpr(expr *Get[any]) (any, error) {
+	return ap.parenthesize("."+expr.name.lexeme, expr.object)
+}
+
 func (ap *AstPrinter) visitGroupingExpr(expr *Grouping[any]) (any, error) {
 	return ap.parenthesize("group", expr.expression)
 }
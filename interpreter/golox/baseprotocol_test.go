@@ -0,0 +1,100 @@
+package golox
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+// baseProtocolTestTransport exchanges the messages of the base protocol with
+// a server running in a goroutine, e.g. the language server or the debug
+// adapter
+type baseProtocolTestTransport struct {
+	t      *testing.T
+	writer *io.PipeWriter
+	// Messages written by the server, read in a goroutine so the server is
+	// never blocked
+	messages chan []byte
+	done     chan error
+}
+
+func newBaseProtocolTestTransport(
+	t *testing.T, serve func(reader io.Reader, writer io.Writer) error,
+) *baseProtocolTestTransport {
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	transport := &baseProtocolTestTransport{
+		t: t, writer: clientWriter, messages: make(chan []byte, 100), done: make(chan error, 1),
+	}
+	go func() {
+		reader := bufio.NewReader(clientReader)
+		for {
+			content, err := readBaseMessage(reader)
+			if err != nil {
+				close(transport.messages)
+				return
+			}
+			transport.messages <- content
+		}
+	}()
+	go func() {
+		err := serve(serverReader, serverWriter)
+		serverWriter.Close()
+		transport.done <- err
+	}()
+	return transport
+}
+
+func (tr *baseProtocolTestTransport) write(content []byte) {
+	if err := writeBaseMessage(tr.writer, content); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+func (tr *baseProtocolTestTransport) writeJson(message any) {
+	tr.write(mustMarshal(tr.t, message))
+}
+
+// Wait for the next message of the server
+func (tr *baseProtocolTestTransport) read() []byte {
+	select {
+	case content, ok := <-tr.messages:
+		if !ok {
+			tr.t.Fatal("the server closed its output")
+		}
+		return content
+	case <-time.After(5 * time.Second):
+		tr.t.Fatal("no message from the server")
+	}
+	return nil
+}
+
+// Wait for the next message of the server and decode it in the value
+func (tr *baseProtocolTestTransport) readJson(value any) {
+	content := tr.read()
+	if err := json.Unmarshal(content, value); err != nil {
+		tr.t.Fatalf("invalid message %s: %s", content, err)
+	}
+}
+
+// Wait for the end of the server, it must end without error
+func (tr *baseProtocolTestTransport) wait() {
+	select {
+	case err := <-tr.done:
+		if err != nil {
+			tr.t.Fatalf("the server failed: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		tr.t.Fatal("the server did not stop")
+	}
+}
+
+func mustMarshal(t *testing.T, value any) []byte {
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
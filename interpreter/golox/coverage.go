@@ -0,0 +1,232 @@
+package golox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Coverage records which statements of a script are executed and which
+// branches of the if statements and of the logical expressions are taken. It
+// is plugged in the interpreter as an ExecutionHook and a BranchHook.
+type Coverage struct {
+	path string
+	// Number of executions of each statement
+	statements map[Stmt[any]]int
+	// Branch points in the order of the source
+	branches []*coverageBranch
+	ifs      map[*If[any]]*coverageBranch
+	logicals map[*Logical[any]]*coverageBranch
+}
+
+// Number of times each side of a branch point is taken: the then and else
+// branches of an if statement, or the left operand only (short-circuit) and
+// the right operand of a logical expression
+type coverageBranch struct {
+	line  int
+	taken [2]int
+}
+
+func NewCoverage(path string, statements []Stmt[any]) *Coverage {
+	c := &Coverage{
+		path:       path,
+		statements: make(map[Stmt[any]]int),
+		ifs:        make(map[*If[any]]*coverageBranch),
+		logicals:   make(map[*Logical[any]]*coverageBranch),
+	}
+	for _, stmt := range statements {
+		c.collectStmt(stmt)
+	}
+	return c
+}
+
+// Register the statements and the branch points that can be covered
+func (c *Coverage) collectStmt(stmt Stmt[any]) {
+	if stmt == nil {
+		return
+	}
+	if stmtLine(stmt) != 0 {
+		c.statements[stmt] = 0
+	}
+	switch stmt := stmt.(type) {
+	case *Block[any]:
+		for _, statement := range stmt.statements {
+			c.collectStmt(statement)
+		}
+	case *Expression[any]:
+		c.collectExpr(stmt.expression)
+	case *If[any]:
+		c.collectExpr(stmt.condition)
+		branch := &coverageBranch{line: stmt.keyword.line}
+		c.ifs[stmt] = branch
+		c.branches = append(c.branches, branch)
+		c.collectStmt(stmt.thenBranch)
+		c.collectStmt(stmt.elseBranch)
+	case *Print[any]:
+		c.collectExpr(stmt.expression)
+	case *Return[any]:
+		c.collectExpr(stmt.value)
+	case *Throw[any]:
+		c.collectExpr(stmt.value)
+	case *Try[any]:
+		c.collectStmt(stmt.body)
+		c.collectStmt(stmt.handler)
+		c.collectStmt(stmt.finalizer)
+	case *Var[any]:
+		c.collectExpr(stmt.initializer)
+	case *While[any]:
+		c.collectExpr(stmt.condition)
+		c.collectStmt(stmt.body)
+	}
+}
+
+func (c *Coverage) collectExpr(expr Expr[any]) {
+	switch expr := expr.(type) {
+	case *Assign[any]:
+		c.collectExpr(expr.value)
+	case *Binary[any]:
+		c.collectExpr(expr.left)
+		c.collectExpr(expr.right)
+	case *Call[any]:
+		c.collectExpr(expr.callee)
+		for _, argument := range expr.arguments {
+			c.collectExpr(argument)
+		}
+	case *Function[any]:
+		for _, value := range expr.defaults {
+			c.collectExpr(value)
+		}
+		for _, statement := range expr.body {
+			c.collectStmt(statement)
+		}
+	case *Get[any]:
+		c.collectExpr(expr.object)
+	case *Grouping[any]:
+		c.collectExpr(expr.expression)
+	case *Logical[any]:
+		c.collectExpr(expr.left)
+		branch := &coverageBranch{line: expr.operator.line}
+		c.logicals[expr] = branch
+		c.branches = append(c.branches, branch)
+		c.collectExpr(expr.right)
+	case *Unary[any]:
+		c.collectExpr(expr.right)
+	}
+}
+
+func (c *Coverage) beforeExecute(interp *Interpreter, stmt Stmt[any]) error {
+	if _, ok := c.statements[stmt]; ok {
+		c.statements[stmt] += 1
+	}
+	return nil
+}
+
+func (c *Coverage) ifBranchTaken(interp *Interpreter, stmt *If[any], isThenBranch bool) {
+	if branch, ok := c.ifs[stmt]; ok {
+		branch.take(isThenBranch)
+	}
+}
+
+func (c *Coverage) logicalBranchTaken(interp *Interpreter, expr *Logical[any], isShortCircuit bool) {
+	if branch, ok := c.logicals[expr]; ok {
+		branch.take(isShortCircuit)
+	}
+}
+
+func (b *coverageBranch) take(isFirst bool) {
+	if isFirst {
+		b.taken[0] += 1
+	} else {
+		b.taken[1] += 1
+	}
+}
+
+// Get the number of executions of each line having a statement, the count of
+// a line is the maximum of the counts of its statements
+func (c *Coverage) lineCounts() ([]int, map[int]int) {
+	counts := make(map[int]int)
+	for stmt, count := range c.statements {
+		line := stmtLine(stmt)
+		if current, ok := counts[line]; !ok || count > current {
+			counts[line] = count
+		}
+	}
+	lines := make([]int, 0, len(counts))
+	for line := range counts {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines, counts
+}
+
+// Write the coverage in the LCOV tracefile format
+func (c *Coverage) WriteLcov(writer io.Writer) {
+	var builder strings.Builder
+	builder.WriteString("TN:\n")
+	builder.WriteString(fmt.Sprintf("SF:%s\n", c.path))
+	branchesHit := 0
+	for i, branch := range c.branches {
+		isEvaluated := branch.taken[0]+branch.taken[1] > 0
+		for j, taken := range branch.taken {
+			if !isEvaluated {
+				// The branch point itself was never reached
+				builder.WriteString(fmt.Sprintf("BRDA:%d,%d,%d,-\n", branch.line, i, j))
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("BRDA:%d,%d,%d,%d\n", branch.line, i, j, taken))
+			if taken > 0 {
+				branchesHit += 1
+			}
+		}
+	}
+	builder.WriteString(fmt.Sprintf("BRF:%d\n", 2*len(c.branches)))
+	builder.WriteString(fmt.Sprintf("BRH:%d\n", branchesHit))
+	lines, counts := c.lineCounts()
+	linesHit := 0
+	for _, line := range lines {
+		builder.WriteString(fmt.Sprintf("DA:%d,%d\n", line, counts[line]))
+		if counts[line] > 0 {
+			linesHit += 1
+		}
+	}
+	builder.WriteString(fmt.Sprintf("LF:%d\n", len(lines)))
+	builder.WriteString(fmt.Sprintf("LH:%d\n", linesHit))
+	builder.WriteString("end_of_record\n")
+	io.WriteString(writer, builder.String())
+}
+
+// Write a summary of the coverage with the lines and branches not covered
+func (c *Coverage) WriteSummary(writer io.Writer) {
+	lines, counts := c.lineCounts()
+	missedLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if counts[line] == 0 {
+			missedLines = append(missedLines, fmt.Sprint(line))
+		}
+	}
+	missedBranches := make([]string, 0, len(c.branches))
+	for _, branch := range c.branches {
+		for j, taken := range branch.taken {
+			if taken == 0 {
+				missedBranches = append(missedBranches, fmt.Sprintf("%d(%d)", branch.line, j))
+			}
+		}
+	}
+	fmt.Fprintf(writer, "Coverage of %s\n", c.path)
+	fmt.Fprintf(writer, "  lines:    %s\n", coveragePercent(len(lines)-len(missedLines), len(lines)))
+	fmt.Fprintf(writer, "  branches: %s\n", coveragePercent(2*len(c.branches)-len(missedBranches), 2*len(c.branches)))
+	if len(missedLines) > 0 {
+		fmt.Fprintf(writer, "  lines not executed: %s\n", strings.Join(missedLines, ", "))
+	}
+	if len(missedBranches) > 0 {
+		fmt.Fprintf(writer, "  branches not taken (line(branch)): %s\n", strings.Join(missedBranches, ", "))
+	}
+}
+
+func coveragePercent(covered int, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", covered, total, 100*float64(covered)/float64(total))
+}
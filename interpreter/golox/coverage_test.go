@@ -0,0 +1,75 @@
+package golox
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		lcov    string
+		summary string
+	}{
+		{
+			name:   "if and logical branches",
+			source: "var x = 1;\nif (x > 0) {\n  print \"positive\";\n} else {\n  print \"negative\";\n}\nprint x > 0 or x < -10;",
+			lcov: "TN:\nSF:test.golox\n" +
+				"BRDA:2,0,0,1\nBRDA:2,0,1,0\nBRDA:7,1,0,1\nBRDA:7,1,1,0\nBRF:4\nBRH:2\n" +
+				"DA:1,1\nDA:2,1\nDA:3,1\nDA:5,0\nDA:7,1\nLF:5\nLH:4\nend_of_record\n",
+			summary: "Coverage of test.golox\n  lines:    4/5 (80.0%)\n  branches: 2/4 (50.0%)\n" +
+				"  lines not executed: 5\n  branches not taken (line(branch)): 2(1), 7(1)\n",
+		},
+		{
+			name:   "function called twice",
+			source: "var f = fun (a) {\n  return a and !a;\n};\nprint f(true);\nprint f(false);\nif (false) print 1;",
+			lcov: "TN:\nSF:test.golox\n" +
+				"BRDA:2,0,0,1\nBRDA:2,0,1,1\nBRDA:6,1,0,0\nBRDA:6,1,1,1\nBRF:4\nBRH:3\n" +
+				"DA:1,1\nDA:2,2\nDA:4,1\nDA:5,1\nDA:6,1\nLF:5\nLH:5\nend_of_record\n",
+			summary: "Coverage of test.golox\n  lines:    5/5 (100.0%)\n  branches: 3/4 (75.0%)\n" +
+				"  branches not taken (line(branch)): 6(0)\n",
+		},
+		{
+			name:   "function never called",
+			source: "var f = fun (a) {\n  return a or 1;\n};\nprint 1;",
+			lcov: "TN:\nSF:test.golox\n" +
+				"BRDA:2,0,0,-\nBRDA:2,0,1,-\nBRF:2\nBRH:0\n" +
+				"DA:1,1\nDA:2,0\nDA:4,1\nLF:3\nLH:2\nend_of_record\n",
+			summary: "Coverage of test.golox\n  lines:    2/3 (66.7%)\n  branches: 0/2 (0.0%)\n" +
+				"  lines not executed: 2\n  branches not taken (line(branch)): 2(0), 2(1)\n",
+		},
+		{
+			name:    "no branch",
+			source:  "print 1;",
+			lcov:    "TN:\nSF:test.golox\nBRF:0\nBRH:0\nDA:1,1\nLF:1\nLH:1\nend_of_record\n",
+			summary: "Coverage of test.golox\n  lines:    1/1 (100.0%)\n  branches: 0/0\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreter()
+			interpreter.SetOutput(io.Discard)
+			coverage := NewCoverage("test.golox", statements)
+			interpreter.SetExecutionHook(coverage)
+			if _, err := interpreter.interpret(context.Background(), statements, false); err != nil {
+				t.Fatal(err)
+			}
+			var lcov, summary strings.Builder
+			coverage.WriteLcov(&lcov)
+			coverage.WriteSummary(&summary)
+			if lcov.String() != test.lcov {
+				t.Errorf("the LCOV file is\n%s\nwant\n%s", lcov.String(), test.lcov)
+			}
+			if summary.String() != test.summary {
+				t.Errorf("the summary is\n%s\nwant\n%s", summary.String(), test.summary)
+			}
+		})
+	}
+}
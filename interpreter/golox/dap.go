@@ -0,0 +1,480 @@
+package golox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// The script is executed in a single thread with a single stack frame
+const (
+	dapThreadId = 1
+	dapFrameId  = 1
+)
+
+// DebugAdapter implements the Debug Adapter Protocol to debug the GoLox
+// scripts from an IDE. The messages are exchanged on the given reader and
+// writer, usually the standard input and output.
+//
+// The script runs in its own goroutine. When the execution is paused, the
+// requests inspecting the state of the interpreter are forwarded to this
+// goroutine so the interpreter is only used by one goroutine at a time.
+type DebugAdapter struct {
+	// Options, arguments and parsing of the scripts launched
+	lox    *GoLox
+	reader *bufio.Reader
+	// Protect the writer and the sequence number used by the two goroutines
+	writeMutex sync.Mutex
+	writer     io.Writer
+	seq        int
+
+	path       string
+	statements []Stmt[any]
+	debugger   *Debugger
+	// Commands sent to the paused execution
+	commands chan dapCommand
+	// Protect the state of the execution shared by the two goroutines
+	stateMutex sync.Mutex
+	isPaused   bool
+	hasPaused  bool
+	line       int
+	isRunning  bool
+	// Mode to resume the execution once the response to the request is sent
+	pendingResume *debugMode
+	// Closed at the end of the execution of the script
+	done chan struct{}
+}
+
+// Command executed by the goroutine of the paused script
+type dapCommand struct {
+	run func(d *Debugger, interp *Interpreter)
+	// Mode used to resume the execution, the execution stays paused if nil
+	resume *debugMode
+	done   chan struct{}
+}
+
+type dapMessage struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command,omitempty"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	RequestSeq int             `json:"request_seq,omitempty"`
+	Success    *bool           `json:"success,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Event      string          `json:"event,omitempty"`
+	Body       any             `json:"body,omitempty"`
+}
+
+func NewDebugAdapter(reader io.Reader, writer io.Writer) *DebugAdapter {
+	return NewDebugAdapterWithGoLox(NewGoLox(), reader, writer)
+}
+
+// Create a debug adapter launching the scripts with the options and the
+// arguments of lox
+func NewDebugAdapterWithGoLox(lox *GoLox, reader io.Reader, writer io.Writer) *DebugAdapter {
+	return &DebugAdapter{
+		lox:      lox,
+		reader:   bufio.NewReader(reader),
+		writer:   writer,
+		commands: make(chan dapCommand),
+		done:     make(chan struct{}),
+	}
+}
+
+// Handle the requests until the disconnection of the client or the end of
+// the input
+func (da *DebugAdapter) Serve() error {
+	defer da.terminate()
+	for {
+		content, err := readBaseMessage(da.reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var request dapMessage
+		if err := json.Unmarshal(content, &request); err != nil {
+			return err
+		}
+		body, err := da.handle(request.Command, request.Arguments)
+		success := err == nil
+		response := &dapMessage{
+			Type:       "response",
+			RequestSeq: request.Seq,
+			Command:    request.Command,
+			Success:    &success,
+			Body:       body,
+		}
+		if err != nil {
+			response.Message = err.Error()
+		}
+		if err := da.send(response); err != nil {
+			return err
+		}
+		if da.pendingResume != nil {
+			da.resume(*da.pendingResume)
+			da.pendingResume = nil
+		}
+		switch request.Command {
+		case "launch":
+			// The client can send the configuration, such as the breakpoints,
+			// once the script is launched
+			if success {
+				if err := da.sendEvent("initialized", nil); err != nil {
+					return err
+				}
+			}
+		case "disconnect", "terminate":
+			return nil
+		}
+	}
+}
+
+func (da *DebugAdapter) handle(command string, arguments json.RawMessage) (any, error) {
+	switch command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args struct {
+			Program     string `json:"program"`
+			StopOnEntry bool   `json:"stopOnEntry"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, da.launch(args.Program, args.StopOnEntry)
+	case "setBreakpoints":
+		var args struct {
+			Source struct {
+				Path string `json:"path"`
+			} `json:"source"`
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, err
+		}
+		if da.debugger == nil {
+			return nil, errors.New("no program launched")
+		}
+		// Only the lines of the script can be stopped at, the breakpoints of
+		// the other sources are not verified
+		isScript := sameFile(args.Source.Path, da.path)
+		if isScript {
+			// The breakpoints of the source replace the previous ones
+			da.debugger.clearBreakpoints()
+		}
+		breakpoints := make([]any, 0, len(args.Breakpoints))
+		for _, breakpoint := range args.Breakpoints {
+			if isScript {
+				da.debugger.setBreakpoint(breakpoint.Line)
+			}
+			breakpoints = append(breakpoints, map[string]any{"verified": isScript, "line": breakpoint.Line})
+		}
+		return map[string]any{"breakpoints": breakpoints}, nil
+	case "setExceptionBreakpoints":
+		return nil, nil
+	case "configurationDone":
+		return nil, da.start()
+	case "threads":
+		return map[string]any{
+			"threads": []any{map[string]any{"id": dapThreadId, "name": "main"}},
+		}, nil
+	case "stackTrace":
+		line, err := da.pausedLine()
+		if err != nil {
+			return nil, err
+		}
+		frame := map[string]any{
+			"id":     dapFrameId,
+			"name":   "main",
+			"line":   line,
+			"column": 1,
+			"source": map[string]any{"path": da.path},
+		}
+		return map[string]any{"stackFrames": []any{frame}, "totalFrames": 1}, nil
+	case "scopes":
+		return da.scopes()
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, err
+		}
+		return da.variables(args.VariablesReference)
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, err
+		}
+		return da.evaluate(args.Expression)
+	case "continue":
+		return map[string]any{"allThreadsContinued": true}, da.requestResume(debugContinue)
+	case "next":
+		return nil, da.requestResume(debugStepOver)
+	case "stepIn":
+		return nil, da.requestResume(debugStepInto)
+	case "stepOut":
+		return nil, da.requestResume(debugStepOut)
+	case "disconnect", "terminate":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported command: %s", command)
+}
+
+func (da *DebugAdapter) launch(path string, stopOnEntry bool) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	statements, err := da.lox.parse(string(bytes))
+	if err != nil {
+		return err
+	}
+	da.path = path
+	da.statements = statements
+	da.debugger = NewDebugger(da)
+	if !stopOnEntry {
+		da.debugger.mode = debugContinue
+	}
+	return nil
+}
+
+// Check if two paths are the paths of the same file
+func sameFile(a string, b string) bool {
+	absoluteA, err := filepath.Abs(a)
+	if err != nil {
+		return false
+	}
+	absoluteB, err := filepath.Abs(b)
+	if err != nil {
+		return false
+	}
+	return absoluteA == absoluteB
+}
+
+// Start the execution of the script once the client is configured
+func (da *DebugAdapter) start() error {
+	if da.debugger == nil {
+		return errors.New("no program launched")
+	}
+	da.stateMutex.Lock()
+	da.isRunning = true
+	da.stateMutex.Unlock()
+	interpreter := NewInterpreterWithOptions(da.lox.options)
+	interpreter.setScriptPath(da.path)
+	interpreter.SetArguments(da.lox.arguments)
+	interpreter.SetOutput(&dapOutput{adapter: da, category: "stdout"})
+	// The standard input carries the protocol, the script reads an empty input
+	interpreter.SetInput(strings.NewReader(""))
+	interpreter.SetExecutionHook(da.debugger)
+	go func() {
+		defer close(da.done)
+		_, err := interpreter.interpret(context.Background(), da.statements, false)
+		exitCode := 0
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.Code()
+		} else if err != nil && !errors.Is(err, errDebuggerQuit) {
+			da.sendEvent("output", map[string]any{"category": "stderr", "output": err.Error() + "\n"})
+			exitCode = 70
+		}
+		da.stateMutex.Lock()
+		da.isRunning = false
+		da.stateMutex.Unlock()
+		da.sendEvent("exited", map[string]any{"exitCode": exitCode})
+		da.sendEvent("terminated", nil)
+	}()
+	return nil
+}
+
+// Stop the execution of the script and wait for its end
+func (da *DebugAdapter) terminate() {
+	da.stateMutex.Lock()
+	isRunning := da.isRunning
+	da.stateMutex.Unlock()
+	if !isRunning {
+		return
+	}
+	// The running script stops at its next statement and the paused script
+	// stops when the commands are closed
+	da.debugger.stop()
+	close(da.commands)
+	<-da.done
+}
+
+// Called by the debugger in the goroutine of the script when the execution
+// is paused. The commands of the client are executed until one resumes it.
+func (da *DebugAdapter) paused(d *Debugger, interp *Interpreter, line int, reason string) (debugMode, error) {
+	da.stateMutex.Lock()
+	if !da.hasPaused && reason == "step" {
+		reason = "entry"
+	}
+	da.isPaused = true
+	da.hasPaused = true
+	da.line = line
+	da.stateMutex.Unlock()
+	err := da.sendEvent("stopped", map[string]any{
+		"reason":            reason,
+		"threadId":          dapThreadId,
+		"allThreadsStopped": true,
+	})
+	if err != nil {
+		return debugQuit, err
+	}
+	for command := range da.commands {
+		if command.run != nil {
+			command.run(d, interp)
+		}
+		if command.resume != nil {
+			da.stateMutex.Lock()
+			da.isPaused = false
+			da.stateMutex.Unlock()
+			close(command.done)
+			return *command.resume, nil
+		}
+		close(command.done)
+	}
+	return debugQuit, nil
+}
+
+func (da *DebugAdapter) pausedLine() (int, error) {
+	da.stateMutex.Lock()
+	defer da.stateMutex.Unlock()
+	if !da.isPaused {
+		return 0, errors.New("the execution is not paused")
+	}
+	return da.line, nil
+}
+
+// Run the function in the goroutine of the paused script and wait for it
+func (da *DebugAdapter) whilePaused(run func(d *Debugger, interp *Interpreter)) error {
+	if _, err := da.pausedLine(); err != nil {
+		return err
+	}
+	command := dapCommand{run: run, done: make(chan struct{})}
+	da.commands <- command
+	<-command.done
+	return nil
+}
+
+// Resume the execution after the response to the request, this way the
+// response is received before the next stopped event
+func (da *DebugAdapter) requestResume(mode debugMode) error {
+	if _, err := da.pausedLine(); err != nil {
+		return err
+	}
+	da.pendingResume = &mode
+	return nil
+}
+
+func (da *DebugAdapter) resume(mode debugMode) {
+	command := dapCommand{resume: &mode, done: make(chan struct{})}
+	da.commands <- command
+	<-command.done
+}
+
+// Each environment of the chain is a scope, its reference is its position in
+// the chain starting at 1 for the current scope
+func (da *DebugAdapter) scopes() (any, error) {
+	scopes := make([]any, 0, 10)
+	err := da.whilePaused(func(d *Debugger, interp *Interpreter) {
+		environments := d.scopes(interp)
+		for i := range environments {
+			name := fmt.Sprintf("Block %d", len(environments)-1-i)
+			if i == 0 {
+				name = "Locals"
+			}
+			if i == len(environments)-1 {
+				name = "Globals"
+			}
+			scopes = append(scopes, map[string]any{
+				"name":               name,
+				"variablesReference": i + 1,
+				"expensive":          false,
+			})
+		}
+	})
+	return map[string]any{"scopes": scopes}, err
+}
+
+func (da *DebugAdapter) variables(reference int) (any, error) {
+	variables := make([]any, 0, 10)
+	err := da.whilePaused(func(d *Debugger, interp *Interpreter) {
+		environments := d.scopes(interp)
+		if reference < 1 || reference > len(environments) {
+			return
+		}
+		environment := environments[reference-1]
+		for _, name := range d.variableNames(environment) {
+			variables = append(variables, map[string]any{
+				"name":               name,
+				"value":              interp.stringify(environment.values[name]),
+				"variablesReference": 0,
+			})
+		}
+	})
+	return map[string]any{"variables": variables}, err
+}
+
+func (da *DebugAdapter) evaluate(expression string) (any, error) {
+	var result any
+	var evalErr error
+	err := da.whilePaused(func(d *Debugger, interp *Interpreter) {
+		value, err := d.evaluate(interp, expression)
+		if err != nil {
+			evalErr = err
+			return
+		}
+		result = map[string]any{"result": interp.stringify(value), "variablesReference": 0}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, evalErr
+}
+
+func (da *DebugAdapter) sendEvent(event string, body any) error {
+	return da.send(&dapMessage{Type: "event", Event: event, Body: body})
+}
+
+func (da *DebugAdapter) send(message *dapMessage) error {
+	da.writeMutex.Lock()
+	defer da.writeMutex.Unlock()
+	da.seq += 1
+	message.Seq = da.seq
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return writeBaseMessage(da.writer, content)
+}
+
+// dapOutput sends the output of the script to the client as events
+type dapOutput struct {
+	adapter  *DebugAdapter
+	category string
+}
+
+func (o *dapOutput) Write(p []byte) (int, error) {
+	err := o.adapter.sendEvent("output", map[string]any{"category": o.category, "output": string(p)})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
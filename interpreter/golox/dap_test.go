@@ -0,0 +1,329 @@
+package golox
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dapTestMessage is a message received from the debug adapter with the body
+// kept for decoding
+type dapTestMessage struct {
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// dapTestClient drives a debug adapter running in a goroutine
+type dapTestClient struct {
+	*baseProtocolTestTransport
+	seq int
+	// Events received and not yet waited for
+	events []*dapTestMessage
+}
+
+func newDapTestClient(t *testing.T, lox *GoLox) *dapTestClient {
+	transport := newBaseProtocolTestTransport(t, func(reader io.Reader, writer io.Writer) error {
+		return NewDebugAdapterWithGoLox(lox, reader, writer).Serve()
+	})
+	return &dapTestClient{baseProtocolTestTransport: transport}
+}
+
+func (c *dapTestClient) readMessage() *dapTestMessage {
+	var message dapTestMessage
+	c.readJson(&message)
+	return &message
+}
+
+// Send a request and wait for its response, the events received in the
+// meantime are recorded
+func (c *dapTestClient) request(command string, arguments any) *dapTestMessage {
+	c.seq += 1
+	c.writeJson(map[string]any{
+		"seq": c.seq, "type": "request", "command": command, "arguments": arguments,
+	})
+	for {
+		message := c.readMessage()
+		if message.Type == "event" {
+			c.events = append(c.events, message)
+			continue
+		}
+		if message.Type != "response" || message.RequestSeq != c.seq || message.Command != command {
+			c.t.Fatalf("unexpected message: %+v", message)
+		}
+		return message
+	}
+}
+
+// Send a request that must succeed and decode the body of its response
+func (c *dapTestClient) call(command string, arguments any, body any) {
+	response := c.request(command, arguments)
+	if !response.Success {
+		c.t.Fatalf("%s failed: %s", command, response.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(response.Body, body); err != nil {
+			c.t.Fatalf("invalid body %s: %s", response.Body, err)
+		}
+	}
+}
+
+// Wait for an event, the events received before are discarded
+func (c *dapTestClient) waitEvent(event string) *dapTestMessage {
+	for {
+		var message *dapTestMessage
+		if len(c.events) > 0 {
+			message, c.events = c.events[0], c.events[1:]
+		} else {
+			message = c.readMessage()
+		}
+		if message.Type == "event" && message.Event == event {
+			return message
+		}
+	}
+}
+
+// Wait for a stopped event and get its reason
+func (c *dapTestClient) waitStopped() string {
+	var stopped struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(c.waitEvent("stopped").Body, &stopped); err != nil {
+		c.t.Fatal(err)
+	}
+	return stopped.Reason
+}
+
+func (c *dapTestClient) pausedLine() int {
+	var stackTrace struct {
+		StackFrames []struct {
+			Line int `json:"line"`
+		} `json:"stackFrames"`
+	}
+	c.call("stackTrace", map[string]any{"threadId": dapThreadId}, &stackTrace)
+	if len(stackTrace.StackFrames) != 1 {
+		c.t.Fatalf("stack frames = %+v", stackTrace.StackFrames)
+	}
+	return stackTrace.StackFrames[0].Line
+}
+
+type dapTestBreakpoints struct {
+	Breakpoints []struct {
+		Verified bool `json:"verified"`
+		Line     int  `json:"line"`
+	} `json:"breakpoints"`
+}
+
+const dapTestSource = `var a = 1;
+var b = a + 1;
+print a + b;
+var c = b * 2;
+print c;
+`
+
+func writeDapTestScript(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "script.golox")
+	if err := os.WriteFile(path, []byte(dapTestSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDebugAdapterSession(t *testing.T) {
+	path := writeDapTestScript(t)
+	client := newDapTestClient(t, NewGoLox())
+	client.call("initialize", map[string]any{"adapterID": "golox"}, nil)
+	client.call("launch", map[string]any{"program": path}, nil)
+	// The configuration is requested once the script is launched
+	for _, event := range client.events {
+		if event.Event == "initialized" {
+			t.Errorf("initialized sent before the response to launch")
+		}
+	}
+	client.waitEvent("initialized")
+
+	var breakpoints dapTestBreakpoints
+	client.call("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": filepath.Join(filepath.Dir(path), "other.golox")},
+		"breakpoints": []any{map[string]any{"line": 4}},
+	}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 1 || breakpoints.Breakpoints[0].Verified {
+		t.Errorf("the breakpoints of another source must not be verified: %+v", breakpoints)
+	}
+	client.call("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []any{map[string]any{"line": 2}},
+	}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 1 || !breakpoints.Breakpoints[0].Verified || breakpoints.Breakpoints[0].Line != 2 {
+		t.Errorf("breakpoints = %+v", breakpoints)
+	}
+	client.call("configurationDone", nil, nil)
+
+	if reason := client.waitStopped(); reason != "breakpoint" {
+		t.Errorf("stopped because of %q", reason)
+	}
+	if line := client.pausedLine(); line != 2 {
+		t.Errorf("paused at line %d", line)
+	}
+	var scopes struct {
+		Scopes []struct {
+			Name               string `json:"name"`
+			VariablesReference int    `json:"variablesReference"`
+		} `json:"scopes"`
+	}
+	client.call("scopes", map[string]any{"frameId": dapFrameId}, &scopes)
+	if len(scopes.Scopes) != 1 || scopes.Scopes[0].Name != "Globals" {
+		t.Fatalf("scopes = %+v", scopes)
+	}
+	var variables struct {
+		Variables []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"variables"`
+	}
+	client.call("variables", map[string]any{"variablesReference": scopes.Scopes[0].VariablesReference}, &variables)
+	found := false
+	for _, variable := range variables.Variables {
+		if variable.Name == "a" {
+			found = variable.Value == "1.000000"
+		}
+	}
+	if !found {
+		t.Errorf("a = 1.000000 is not in the variables %+v", variables.Variables)
+	}
+	var evaluation struct {
+		Result string `json:"result"`
+	}
+	client.call("evaluate", map[string]any{"expression": "a + 10"}, &evaluation)
+	if evaluation.Result != "11.000000" {
+		t.Errorf("a + 10 = %q", evaluation.Result)
+	}
+
+	client.call("next", map[string]any{"threadId": dapThreadId}, nil)
+	if reason := client.waitStopped(); reason != "step" {
+		t.Errorf("stopped because of %q", reason)
+	}
+	if line := client.pausedLine(); line != 3 {
+		t.Errorf("paused at line %d after next", line)
+	}
+
+	client.call("continue", map[string]any{"threadId": dapThreadId}, nil)
+	output := strings.Builder{}
+	for {
+		event := client.waitEvent("output")
+		var body struct {
+			Output string `json:"output"`
+		}
+		if err := json.Unmarshal(event.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		output.WriteString(body.Output)
+		if output.String() == "3.000000\n4.000000\n" {
+			break
+		} else if !strings.HasPrefix("3.000000\n4.000000\n", output.String()) {
+			t.Fatalf("output = %q", output.String())
+		}
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	if err := json.Unmarshal(client.waitEvent("exited").Body, &exited); err != nil {
+		t.Fatal(err)
+	}
+	if exited.ExitCode != 0 {
+		t.Errorf("exit code = %d", exited.ExitCode)
+	}
+	client.waitEvent("terminated")
+	client.call("disconnect", nil, nil)
+	client.wait()
+}
+
+func TestDebugAdapterBreakpointsBeforeLaunch(t *testing.T) {
+	client := newDapTestClient(t, NewGoLox())
+	client.call("initialize", map[string]any{"adapterID": "golox"}, nil)
+	response := client.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": "script.golox"},
+		"breakpoints": []any{map[string]any{"line": 1}},
+	})
+	if response.Success {
+		t.Errorf("the breakpoints are set without program")
+	}
+	client.call("disconnect", nil, nil)
+	client.wait()
+}
+
+func TestDebugAdapterInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.golox")
+	if err := os.WriteFile(path, []byte("print input();\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client := newDapTestClient(t, NewGoLox())
+	client.call("initialize", map[string]any{"adapterID": "golox"}, nil)
+	client.call("launch", map[string]any{"program": path}, nil)
+	client.call("configurationDone", nil, nil)
+	// The standard input carries the protocol, the script reads an empty input
+	var output struct {
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal(client.waitEvent("output").Body, &output); err != nil {
+		t.Fatal(err)
+	}
+	if output.Output != "nil\n" {
+		t.Errorf("output = %q", output.Output)
+	}
+	client.waitEvent("terminated")
+	client.call("disconnect", nil, nil)
+	client.wait()
+}
+
+func TestDebugAdapterOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.golox")
+	if err := os.WriteFile(path, []byte("print args();\nprint 1;\nprint 2;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lox := NewGoLox()
+	lox.SetInterpreterOptions(InterpreterOptions{MaxSteps: 2})
+	lox.SetArguments([]string{"one"})
+	client := newDapTestClient(t, lox)
+	client.call("initialize", map[string]any{"adapterID": "golox"}, nil)
+	client.call("launch", map[string]any{"program": path}, nil)
+	client.call("configurationDone", nil, nil)
+	// The script gets the arguments and stops at the limit of steps
+	output := strings.Builder{}
+	for {
+		event := client.waitEvent("output")
+		var body struct {
+			Output string `json:"output"`
+		}
+		if err := json.Unmarshal(event.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		output.WriteString(body.Output)
+		if strings.Count(output.String(), "\n") == 3 {
+			break
+		}
+	}
+	want := "[one]\n1.000000\n[line 3] LIMIT ERROR: "
+	if !strings.HasPrefix(output.String(), want) {
+		t.Errorf("output = %q, want it to start with %q", output.String(), want)
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	if err := json.Unmarshal(client.waitEvent("exited").Body, &exited); err != nil {
+		t.Fatal(err)
+	}
+	if exited.ExitCode != 70 {
+		t.Errorf("exit code = %d", exited.ExitCode)
+	}
+	client.waitEvent("terminated")
+	client.call("disconnect", nil, nil)
+	client.wait()
+}
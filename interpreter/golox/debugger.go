@@ -0,0 +1,289 @@
+package golox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var errDebuggerQuit = errors.New("execution stopped by the debugger")
+
+// How the execution resumes after a pause
+type debugMode int
+
+const (
+	// Run until the next breakpoint
+	debugContinue debugMode = iota
+	// Pause at the next statement
+	debugStepInto
+	// Pause at the next statement that is not nested in the current one
+	debugStepOver
+	// Pause at the next statement outside of the current block
+	debugStepOut
+	// Stop the execution
+	debugQuit
+)
+
+// debugFrontend interacts with the user while the execution is paused and
+// returns how the execution must be resumed
+type debugFrontend interface {
+	paused(d *Debugger, interp *Interpreter, line int, reason string) (debugMode, error)
+}
+
+// Debugger pauses the execution of a script at the breakpoints and after each
+// step. It is plugged in the interpreter as an ExecutionHook and delegates the
+// interaction with the user to a frontend (console, debug adapter, ...).
+type Debugger struct {
+	// Protect the breakpoints and the stop request that can be modified while
+	// the script is running
+	mutex       sync.Mutex
+	breakpoints map[int]bool
+	isStopped   bool
+	mode        debugMode
+	// Depth of the statement where the last step started
+	stepDepth int
+	// Line of the last statement executed
+	previousLine int
+	frontend     debugFrontend
+}
+
+func NewDebugger(frontend debugFrontend) *Debugger {
+	// The execution is paused before the first statement
+	return &Debugger{breakpoints: make(map[int]bool), mode: debugStepInto, frontend: frontend}
+}
+
+func (d *Debugger) beforeExecute(interp *Interpreter, stmt Stmt[any]) error {
+	line := stmtLine(stmt)
+	if line == 0 {
+		return nil
+	}
+	d.mutex.Lock()
+	isStopped, isBreakpoint := d.isStopped, d.breakpoints[line]
+	d.mutex.Unlock()
+	if isStopped {
+		return errDebuggerQuit
+	}
+	reason := ""
+	switch d.mode {
+	case debugStepInto:
+		reason = "step"
+	case debugStepOver:
+		if interp.depth <= d.stepDepth {
+			reason = "step"
+		}
+	case debugStepOut:
+		if interp.depth < d.stepDepth {
+			reason = "step"
+		}
+	}
+	// A breakpoint is hit once even if several statements are on the line
+	if reason == "" && isBreakpoint && line != d.previousLine {
+		reason = "breakpoint"
+	}
+	d.previousLine = line
+	if reason == "" {
+		return nil
+	}
+	mode, err := d.frontend.paused(d, interp, line, reason)
+	if err != nil {
+		return err
+	}
+	if mode == debugQuit {
+		return errDebuggerQuit
+	}
+	d.mode = mode
+	d.stepDepth = interp.depth
+	return nil
+}
+
+func (d *Debugger) setBreakpoint(line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints[line] = true
+}
+
+func (d *Debugger) clearBreakpoint(line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.breakpoints, line)
+}
+
+func (d *Debugger) clearBreakpoints() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints = make(map[int]bool)
+}
+
+// Get the lines of the breakpoints in ascending order
+func (d *Debugger) breakpointLines() []int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Stop the execution at the next statement
+func (d *Debugger) stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.isStopped = true
+}
+
+// Evaluate an expression in the scope where the execution is paused
+func (d *Debugger) evaluate(interp *Interpreter, source string) (any, error) {
+	scanner := NewScanner(source, 20)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		return nil, err
+	}
+	parser := NewParser[any](len(tokens))
+	parser.tokens = append(parser.tokens, tokens...)
+	expr, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.isAtEnd() {
+		return nil, NewSyntaxError(parser.peek().line, "expect the end of the expression")
+	}
+	return interp.evaluate(expr)
+}
+
+// Get the environments from the current scope to the global scope
+func (d *Debugger) scopes(interp *Interpreter) []*Environment {
+	scopes := make([]*Environment, 0, 10)
+	for environment := interp.environment; environment != nil; environment = environment.enclosing {
+		scopes = append(scopes, environment)
+	}
+	return scopes
+}
+
+// Get the names of the variables of the environment in alphabetical order
+func (d *Debugger) variableNames(environment *Environment) []string {
+	names := make([]string, 0, len(environment.values))
+	for name := range environment.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// debugConsole is the frontend of the debugger reading the commands of the
+// user in a terminal
+type debugConsole struct {
+	reader *bufio.Reader
+	writer io.Writer
+	lines  []string
+}
+
+func newDebugConsole(source string, reader io.Reader, writer io.Writer) *debugConsole {
+	return &debugConsole{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+		lines:  strings.Split(source, "\n"),
+	}
+}
+
+const debugConsoleHelp = `Commands:
+  c, continue       run until the next breakpoint
+  s, step           step into the next statement
+  n, next           step over the current statement
+  o, out            step out of the current block
+  b, break LINE     add a breakpoint
+  d, delete LINE    remove a breakpoint
+  breakpoints       list the breakpoints
+  p, print EXPR     evaluate an expression in the current scope
+  e, env            show the variables of the scopes
+  l, list           show the source around the current line
+  q, quit           stop the execution
+  h, help           show this help`
+
+func (c *debugConsole) paused(d *Debugger, interp *Interpreter, line int, reason string) (debugMode, error) {
+	fmt.Fprintf(c.writer, "Paused at line %d (%s)\n", line, reason)
+	c.list(line, 0)
+	for {
+		fmt.Fprint(c.writer, "(debug) ")
+		input, err := c.reader.ReadString('\n')
+		if errors.Is(err, io.EOF) && input == "" {
+			return debugQuit, nil
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return debugQuit, err
+		}
+		command, argument, _ := strings.Cut(strings.TrimSpace(input), " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		case "c", "continue":
+			return debugContinue, nil
+		case "s", "step":
+			return debugStepInto, nil
+		case "n", "next":
+			return debugStepOver, nil
+		case "o", "out":
+			return debugStepOut, nil
+		case "q", "quit":
+			return debugQuit, nil
+		case "b", "break", "d", "delete":
+			breakpointLine, err := strconv.Atoi(argument)
+			if err != nil {
+				fmt.Fprintf(c.writer, "invalid line: %q\n", argument)
+			} else if command == "b" || command == "break" {
+				d.setBreakpoint(breakpointLine)
+			} else {
+				d.clearBreakpoint(breakpointLine)
+			}
+		case "breakpoints":
+			for _, breakpointLine := range d.breakpointLines() {
+				c.list(breakpointLine, 0)
+			}
+		case "p", "print":
+			value, err := d.evaluate(interp, argument)
+			if err != nil {
+				fmt.Fprintln(c.writer, err)
+			} else {
+				fmt.Fprintln(c.writer, interp.stringify(value))
+			}
+		case "e", "env":
+			c.env(d, interp)
+		case "l", "list":
+			c.list(line, 3)
+		case "h", "help":
+			fmt.Fprintln(c.writer, debugConsoleHelp)
+		case "":
+		default:
+			fmt.Fprintf(c.writer, "unknown command: %q, type 'help' for the list of commands\n", command)
+		}
+	}
+}
+
+func (c *debugConsole) env(d *Debugger, interp *Interpreter) {
+	scopes := d.scopes(interp)
+	for i, environment := range scopes {
+		if i == len(scopes)-1 {
+			fmt.Fprintln(c.writer, "global scope:")
+		} else {
+			fmt.Fprintf(c.writer, "scope %d:\n", len(scopes)-1-i)
+		}
+		for _, name := range d.variableNames(environment) {
+			fmt.Fprintf(c.writer, "  %s = %s\n", name, interp.stringify(environment.values[name]))
+		}
+	}
+}
+
+// Show the lines of the source around the given line
+func (c *debugConsole) list(line int, around int) {
+	for i := max(line-around, 1); i <= min(line+around, len(c.lines)); i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(c.writer, "%s %4d | %s\n", marker, i, strings.TrimRight(c.lines[i-1], "\r"))
+	}
+}
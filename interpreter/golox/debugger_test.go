@@ -0,0 +1,136 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var debuggerPausePattern = regexp.MustCompile(`Paused at line \d+ \(\w+\)`)
+
+// Run a script in the debugger with the commands of the console and check
+// where it pauses, what the console shows and what the script prints
+func TestDebugger(t *testing.T) {
+	source := "var f = fun (x) {\n  var y = x * 2;\n  return y;\n};\nvar a = f(1);\nprint a;\nprint f(2);\n"
+	tests := []struct {
+		name     string
+		commands string
+		pauses   []string
+		// Texts shown by the console
+		console []string
+		output  string
+		// Stopped by the debugger before the end of the script
+		quit bool
+	}{
+		{
+			name:     "step into",
+			commands: "s\ns\ns\ns\nc\n",
+			pauses: []string{
+				"Paused at line 1 (step)", "Paused at line 5 (step)", "Paused at line 2 (step)",
+				"Paused at line 3 (step)", "Paused at line 6 (step)",
+			},
+			output: "2.000000\n4.000000\n",
+		},
+		{
+			name:     "step over",
+			commands: "n\nn\nn\nc\n",
+			pauses: []string{
+				"Paused at line 1 (step)", "Paused at line 5 (step)", "Paused at line 6 (step)",
+				"Paused at line 7 (step)",
+			},
+			output: "2.000000\n4.000000\n",
+		},
+		{
+			name:     "step out",
+			commands: "s\ns\no\nc\n",
+			pauses: []string{
+				"Paused at line 1 (step)", "Paused at line 5 (step)", "Paused at line 2 (step)",
+				"Paused at line 6 (step)",
+			},
+			output: "2.000000\n4.000000\n",
+		},
+		{
+			name:     "breakpoint",
+			commands: "b 3\nc\np y\nc\np x + y\nc\n",
+			pauses: []string{
+				"Paused at line 1 (step)", "Paused at line 3 (breakpoint)", "Paused at line 3 (breakpoint)",
+			},
+			console: []string{"(debug) 2.000000\n", "(debug) 6.000000\n"},
+			output:  "2.000000\n4.000000\n",
+		},
+		{
+			name:     "deleted breakpoint",
+			commands: "b 3\nd 3\nb 6\nbreakpoints\nc\nc\n",
+			pauses:   []string{"Paused at line 1 (step)", "Paused at line 6 (breakpoint)"},
+			console:  []string{"(debug) >    6 | print a;\n"},
+			output:   "2.000000\n4.000000\n",
+		},
+		{
+			name:     "variables of the scopes",
+			commands: "s\ns\ns\ne\nl\nc\n",
+			pauses: []string{
+				"Paused at line 1 (step)", "Paused at line 5 (step)", "Paused at line 2 (step)",
+				"Paused at line 3 (step)",
+			},
+			console: []string{
+				"scope 1:\n  x = 1.000000\n  y = 2.000000\nglobal scope:\n",
+				"  f = <function>\n",
+				"     2 |   var y = x * 2;\n>    3 |   return y;\n     4 | };\n",
+			},
+			output: "2.000000\n4.000000\n",
+		},
+		{
+			name:     "invalid commands",
+			commands: "b x\nfoo\np 1 +\nc\n",
+			pauses:   []string{"Paused at line 1 (step)"},
+			console: []string{
+				"invalid line: \"x\"\n",
+				"unknown command: \"foo\", type 'help' for the list of commands\n",
+				"[line 1] SYNTAX ERROR: ",
+			},
+			output: "2.000000\n4.000000\n",
+		},
+		{
+			name:     "quit",
+			commands: "s\nq\n",
+			pauses:   []string{"Paused at line 1 (step)", "Paused at line 5 (step)"},
+			quit:     true,
+		},
+		{
+			name:   "end of the input",
+			pauses: []string{"Paused at line 1 (step)"},
+			quit:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := NewGoLox().parse(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreter()
+			output := &strings.Builder{}
+			interpreter.SetOutput(output)
+			console := &strings.Builder{}
+			interpreter.SetExecutionHook(NewDebugger(newDebugConsole(source, strings.NewReader(test.commands), console)))
+			_, err = interpreter.interpret(context.Background(), statements, false)
+			if test.quit != errors.Is(err, errDebuggerQuit) || (!test.quit && err != nil) {
+				t.Errorf("the script failed with %v", err)
+			}
+			if pauses := debuggerPausePattern.FindAllString(console.String(), -1); fmt.Sprint(pauses) != fmt.Sprint(test.pauses) {
+				t.Errorf("the debugger paused at %q, want %q", pauses, test.pauses)
+			}
+			for _, text := range test.console {
+				if !strings.Contains(console.String(), text) {
+					t.Errorf("the console does not show %q:\n%s", text, console.String())
+				}
+			}
+			if output.String() != test.output {
+				t.Errorf("the script printed %q, want %q", output.String(), test.output)
+			}
+		})
+	}
+}
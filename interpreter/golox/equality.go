@@ -0,0 +1,46 @@
+package golox
+
+import "reflect"
+
+// Check if two values are equal for == and !=. The numbers are compared by
+// value following IEEE 754: NaN is not equal to itself and -0 is equal to 0.
+// The strings are compared by content, the values of different types are never
+// equal and the other values, e.g. the lists and the functions, are equal only
+// if they are the same object.
+func isEqual(left any, right any) bool {
+	switch left := left.(type) {
+	case nil:
+		return right == nil
+	case bool:
+		right, ok := right.(bool)
+		return ok && left == right
+	case float64:
+		right, ok := right.(float64)
+		return ok && left == right
+	case string:
+		right, ok := right.(string)
+		return ok && left == right
+	}
+	// Comparing values of a type that is not comparable panics
+	leftType := reflect.TypeOf(left)
+	return leftType == reflect.TypeOf(right) && leftType.Comparable() && left == right
+}
+
+// Check if two values are equal comparing the elements of the lists instead of
+// their identity
+func isDeepEqual(left any, right any) bool {
+	leftList, ok := left.(*LoxList)
+	if !ok {
+		return isEqual(left, right)
+	}
+	rightList, ok := right.(*LoxList)
+	if !ok || len(leftList.elements) != len(rightList.elements) {
+		return false
+	}
+	for i, element := range leftList.elements {
+		if !isDeepEqual(element, rightList.elements[i]) {
+			return false
+		}
+	}
+	return true
+}
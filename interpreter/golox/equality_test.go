@@ -0,0 +1,58 @@
+package golox
+
+import "testing"
+
+func TestEquality(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+	}{
+		{
+			name:   "primitive values",
+			source: "print 1 == 1;\nprint \"ab\" == \"a\" + \"b\";\nprint nil == nil;\nprint true != false;",
+			output: "true\ntrue\ntrue\ntrue\n",
+		},
+		{
+			name:   "values of different types",
+			source: "print 1 == \"1\";\nprint nil == false;\nprint 0 == false;\nprint range(1) == nil;",
+			output: "false\nfalse\nfalse\nfalse\n",
+		},
+		{
+			name:   "numbers",
+			source: "print NAN == NAN;\nprint NAN != NAN;\nprint equals(NAN, NAN);\nprint 0 == -0;\nprint INF == INF;",
+			output: "false\ntrue\nfalse\ntrue\ntrue\n",
+		},
+		{
+			name:   "lists",
+			source: "var a = range(3);\nvar b = range(3);\nprint a == a;\nprint a == b;\nprint a != b;",
+			output: "true\nfalse\ntrue\n",
+		},
+		{
+			name: "equals on lists",
+			source: "var a = range(3);\nprint equals(a, range(3));\nprint equals(a, range(2));\nprint equals(range(0), range(0));\n" +
+				"print equals(range(1), 0);\nprint equals(0, range(1));",
+			output: "true\nfalse\ntrue\nfalse\nfalse\n",
+		},
+		{
+			name:   "equals on nested lists",
+			source: "var nested = fun () { return map(range(2), fun (x) { return range(x); }); };\nprint equals(nested(), nested());",
+			output: "true\n",
+		},
+		{
+			name:   "functions",
+			source: "var f = fun () {};\nprint f == f;\nprint f == fun () {};\nprint clock == clock;\nprint equals(f, f);",
+			output: "true\nfalse\ntrue\ntrue\n",
+		},
+		{
+			name:   "caught errors",
+			source: "try { throw \"x\"; } catch (e) {\n  try { throw \"x\"; } catch (f) {\n    print e == f;\n    print e == e;\n  }\n}",
+			output: "false\ntrue\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkTestScript(t, test.source, test.output, "")
+		})
+	}
+}
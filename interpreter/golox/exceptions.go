@@ -0,0 +1,80 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LoxError is the value of an error caught by a try statement, the scripts
+// read its message, line and kind as properties
+type LoxError struct {
+	kind    string
+	message string
+	line    int
+	// Value given to the throw statement, nil for the errors of the runtime
+	value any
+	// Error as reported when it is not caught
+	description string
+}
+
+func (e *LoxError) String() string {
+	return e.kind + ": " + e.message
+}
+
+func (e *LoxError) get(name *Token) (any, error) {
+	switch name.lexeme {
+	case "message":
+		return e.message, nil
+	case "line":
+		return float64(e.line), nil
+	case "kind":
+		return e.kind, nil
+	case "value":
+		return e.value, nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("an error has no property '%s'", name.lexeme))
+}
+
+// Get the value given to a catch clause for an error. The errors stopping the
+// script (limits, cancellation, exit, ...) cannot be caught.
+func caughtError(err error) (*LoxError, bool) {
+	var throwErr *ThrowError
+	var runtimeErr *RuntimeError
+	var syntaxErr *SyntaxError
+	if errors.As(err, &throwErr) {
+		if loxError, ok := throwErr.value.(*LoxError); ok {
+			return loxError, true
+		}
+		return &LoxError{
+			kind:        "Error",
+			message:     throwErr.message,
+			line:        throwErr.line,
+			value:       throwErr.value,
+			description: err.Error(),
+		}, true
+	} else if errors.As(err, &runtimeErr) {
+		return &LoxError{
+			kind:        runtimeErr.kind,
+			message:     runtimeErr.message,
+			line:        runtimeErr.token.line,
+			description: err.Error(),
+		}, true
+	} else if errors.As(err, &syntaxErr) {
+		// Syntax error of an imported module
+		return &LoxError{
+			kind:        "SyntaxError",
+			message:     syntaxErr.message,
+			line:        syntaxErr.line,
+			description: err.Error(),
+		}, true
+	}
+	return nil, false
+}
+
+// Check if the error must stop the script without executing the finally
+// clauses
+func stopsScript(err error) bool {
+	var cancellationErr *CancellationError
+	_, isLimitErr := err.(limitError)
+	return isLimitErr || errors.As(err, &cancellationErr) || errors.Is(err, errDebuggerQuit)
+}
@@ -56,6 +56,44 @@ func (e *Call[T]) accept(visitor ExprVisitor[T]) (T, error){
     return visitor.visitCallExpr(e)
 }
 
+type Function[T any] struct {
+    keyword *Token
+    params []*Token
+    defaults []Expr[T]
+    rest *Token
+    body []Stmt[T]
+}
+
+func NewFunction[T any](keyword *Token, params []*Token, defaults []Expr[T], rest *Token, body []Stmt[T]) *Function[T] {
+    return &Function[T]{
+        keyword: keyword,
+        params: params,
+        defaults: defaults,
+        rest: rest,
+        body: body,
+    }
+}
+
+func (e *Function[T]) accept(visitor ExprVisitor[T]) (T, error){
+    return visitor.visitFunctionExpr(e)
+}
+
+type Get[T any] struct {
+    object Expr[T]
+    name *Token
+}
+
+func NewGet[T any](object Expr[T], name *Token) *Get[T] {
+    return &Get[T]{
+        object: object,
+        name: name,
+    }
+}
+
+func (e *Get[T]) accept(visitor ExprVisitor[T]) (T, error){
+    return visitor.visitGetExpr(e)
+}
+
 type Grouping[T any] struct {
     expression Expr[T]
 }
@@ -136,6 +174,8 @@ type ExprVisitor[T any] interface {
     visitAssignExpr(expr *Assign[T]) (T, error)
     visitBinaryExpr(expr *Binary[T]) (T, error)
     visitCallExpr(expr *Call[T]) (T, error)
+    visitFunctionExpr(expr *Function[T]) (T, error)
+    visitGetExpr(expr *Get[T]) (T, error)
     visitGroupingExpr(expr *Grouping[T]) (T, error)
     visitLiteralExpr(expr *Literal[T]) (T, error)
     visitLogicalExpr(expr *Logical[T]) (T, error)
@@ -0,0 +1,151 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Natives accessing the file system, registered with readFile when the
+// interpreter is allowed to access the file system
+var fileNatives = []*NativeFunction{
+	NewNativeFunction("writeFile", 2, nativeWriteFile),
+	NewNativeFunction("appendFile", 2, nativeAppendFile),
+	NewNativeFunction("readLines", 1, nativeReadLines),
+	NewNativeFunction("exists", 1, nativeExists),
+	NewNativeFunction("listDir", 1, nativeListDir),
+	NewNativeFunction("remove", 1, nativeRemove),
+}
+
+// Get the path given as argument of a native, the path must be allowed by the
+// options of the interpreter
+func pathArgument(interp *Interpreter, function string, value any) (string, error) {
+	path, err := stringArgument(function, "path", value)
+	if err != nil {
+		return "", err
+	}
+	path, err = allowedPath(interp, path)
+	if err != nil {
+		return "", fileError(function, err)
+	}
+	return path, nil
+}
+
+// Prefix the error of a file operation with the name of the native
+func fileError(function string, err error) error {
+	if _, ok := err.(limitError); ok {
+		return err
+	}
+	return fmt.Errorf("%s: %w", function, err)
+}
+
+// Replace the content of a file, the file is created if needed
+func nativeWriteFile(interp *Interpreter, args []any) (any, error) {
+	return nil, writeFile(interp, "writeFile", args, os.O_TRUNC)
+}
+
+// Add a content at the end of a file, the file is created if needed
+func nativeAppendFile(interp *Interpreter, args []any) (any, error) {
+	return nil, writeFile(interp, "appendFile", args, os.O_APPEND)
+}
+
+func writeFile(interp *Interpreter, function string, args []any, mode int) error {
+	path, err := pathArgument(interp, function, args[0])
+	if err != nil {
+		return err
+	}
+	content, err := stringArgument(function, "content", args[1])
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0644)
+	if err != nil {
+		return fileError(function, err)
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileError(function, err)
+	}
+	return nil
+}
+
+// Get the lines of a file without their line terminators
+func nativeReadLines(interp *Interpreter, args []any) (any, error) {
+	path, err := pathArgument(interp, "readLines", args[0])
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileError("readLines", err)
+	}
+	content := strings.TrimSuffix(string(bytes), "\n")
+	if content == "" {
+		return NewLoxList([]any{}), nil
+	}
+	lines := strings.Split(content, "\n")
+	err = interp.checkSize(0, len(lines))
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, len(lines))
+	for i, line := range lines {
+		elements[i] = strings.TrimSuffix(line, "\r")
+	}
+	return NewLoxList(elements), nil
+}
+
+func nativeExists(interp *Interpreter, args []any) (any, error) {
+	path, err := pathArgument(interp, "exists", args[0])
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return nil, fileError("exists", err)
+	}
+	return true, nil
+}
+
+// Get the names of the entries of a directory in alphabetical order
+func nativeListDir(interp *Interpreter, args []any) (any, error) {
+	path, err := pathArgument(interp, "listDir", args[0])
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fileError("listDir", err)
+	}
+	err = interp.checkSize(0, len(entries))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]any, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return NewLoxList(names), nil
+}
+
+// Remove a file or an empty directory
+func nativeRemove(interp *Interpreter, args []any) (any, error) {
+	path, err := pathArgument(interp, "remove", args[0])
+	if err != nil {
+		return nil, err
+	}
+	err = os.Remove(path)
+	if err != nil {
+		return nil, fileError("remove", err)
+	}
+	return nil, nil
+}
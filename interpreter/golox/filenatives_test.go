@@ -0,0 +1,84 @@
+package golox
+
+import "testing"
+
+// The natives work in a temporary working directory, the relative paths are
+// relative to it
+func TestFileNatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Error of the script, no error if empty
+		err string
+	}{
+		{
+			name:   "write and read",
+			source: "writeFile(\"a.txt\", \"x\");\nwriteFile(\"a.txt\", \"y\");\nprint readFile(\"a.txt\") + \"!\";\nprint readFile(\"a.txt\") == \"y\";",
+			output: "y!\ntrue\n",
+		},
+		{
+			name: "append and read the lines",
+			source: "writeFile(\"a.txt\", \"one\n\");\nappendFile(\"a.txt\", \"two\r\nthree\n\");\nappendFile(\"b.txt\", \"new\");\n" +
+				"print readLines(\"a.txt\");\nprint readLines(\"b.txt\");",
+			output: "[one, two, three]\n[new]\n",
+		},
+		{
+			name:   "exists and remove",
+			source: "print exists(\"a.txt\");\nwriteFile(\"a.txt\", \"\");\nprint exists(\"a.txt\");\nprint readLines(\"a.txt\");\nremove(\"a.txt\");\nprint exists(\"a.txt\");",
+			output: "false\ntrue\n[]\nfalse\n",
+		},
+		{
+			name:   "exists in a missing directory",
+			source: "print exists(\"missing/a.txt\");",
+			output: "false\n",
+		},
+		{
+			name:   "list a directory",
+			source: "writeFile(\"b.txt\", \"\");\nwriteFile(\"a.txt\", \"\");\nprint listDir(\".\");",
+			output: "[a.txt, b.txt]\n",
+		},
+		{
+			name:   "read a missing file",
+			source: "print 1;\nprint readFile(\"missing.txt\");",
+			output: "1.000000\n",
+			err:    "[line 2] RUNTIME ERROR: readFile: open missing.txt: no such file or directory",
+		},
+		{
+			name:   "read the lines of a missing file",
+			source: "print readLines(\"missing.txt\");",
+			err:    "[line 1] RUNTIME ERROR: readLines: open missing.txt: no such file or directory",
+		},
+		{
+			name:   "remove a missing file",
+			source: "remove(\"missing.txt\");",
+			err:    "[line 1] RUNTIME ERROR: remove: remove missing.txt: no such file or directory",
+		},
+		{
+			name:   "list a missing directory",
+			source: "print listDir(\"missing\");",
+			err:    "[line 1] RUNTIME ERROR: listDir: open missing: no such file or directory",
+		},
+		{
+			name:   "write in a missing directory",
+			source: "writeFile(\"missing/a.txt\", \"x\");",
+			err:    "[line 1] RUNTIME ERROR: writeFile: open missing/a.txt: no such file or directory",
+		},
+		{
+			name:   "content not a string",
+			source: "writeFile(\"a.txt\", 1);",
+			err:    "[line 1] RUNTIME ERROR: writeFile: the argument 'content' must be a string, got number",
+		},
+		{
+			name:   "path not a string",
+			source: "appendFile(nil, \"x\");",
+			err:    "[line 1] RUNTIME ERROR: appendFile: the argument 'path' must be a string, got nil",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirForTest(t, t.TempDir())
+			checkTestScript(t, test.source, test.output, test.err)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package golox
+
+import (
+	"strings"
+)
+
+const formatterIndentation = "  "
+
+// Formatter rewrites a source code with a canonical layout: one statement by
+// line, an indentation of two spaces by block, a space around the binary
+// operators and the opening braces on the same line as their statement.
+// The comments are kept at their place.
+//
+// The formatter works on the tokens and not on the AST to keep the source as
+// written by the user (e.g. a for loop is not desugared into a while loop).
+type Formatter struct {
+	tokens  []*Token
+	current int
+
+	lines  []string
+	line   strings.Builder
+	indent int
+	// The next token must start a new line
+	pendingNewline bool
+	// Line in the source of the last token written
+	lastLine int
+	// Number of additional indentations for the bodies without braces of the
+	// control flow statements of the current block
+	bodyIndents int
+	// Indentations of the if statements of the current block waiting for a
+	// body without braces or for their else branch
+	ifIndents []int
+	// Opening parenthesis not closed yet with the keyword of the control flow
+	// statement if the parenthesis starts its clauses, or LEFT_PAREN
+	parens []TokenType
+	// State of the enclosing blocks saved while formatting a block
+	blocks []formatterBlock
+	// The parameters of a function expression were just closed, the next
+	// brace opens its body
+	functionBody bool
+}
+
+type formatterBlock struct {
+	bodyIndents int
+	ifIndents   []int
+	parens      []TokenType
+	// The block is the body of a function expression, the statement
+	// containing the expression continues after the block
+	isFunction bool
+}
+
+func NewFormatter() *Formatter {
+	return &Formatter{}
+}
+
+// Format the source code. The source must be syntactically valid.
+func (f *Formatter) Format(source string) (string, error) {
+	scanner := NewScannerWithComments(source, 100)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		return "", err
+	}
+	// Check the syntax before formatting
+	parser := NewParser[any](len(tokens))
+	for _, token := range tokens {
+		if token.tokenType != COMMENT {
+			parser.tokens = append(parser.tokens, token)
+		}
+	}
+	_, err = parser.Parse()
+	if err != nil {
+		return "", err
+	}
+	*f = Formatter{tokens: tokens}
+	for !f.isAtEnd() {
+		f.format(f.next())
+	}
+	f.newline()
+	return strings.Join(f.lines, "\n") + "\n", nil
+}
+
+func (f *Formatter) format(token *Token) {
+	if token.tokenType == COMMENT {
+		f.formatComment(token)
+		return
+	}
+	if f.pendingNewline {
+		f.pendingNewline = false
+		f.newline()
+		f.blankLine(token)
+	}
+	switch token.tokenType {
+	case LEFT_BRACE:
+		previous := f.previous()
+		isFunction := f.functionBody || (previous != nil && previous.tokenType == ARROW)
+		f.functionBody = false
+		f.write(token, true)
+		f.blocks = append(f.blocks, formatterBlock{
+			bodyIndents: f.bodyIndents, ifIndents: f.ifIndents, parens: f.parens, isFunction: isFunction,
+		})
+		f.bodyIndents = 0
+		f.ifIndents = nil
+		f.parens = nil
+		f.indent += 1
+		f.pendingNewline = true
+	case RIGHT_BRACE:
+		f.newline()
+		f.indent -= 1 + f.bodyIndents
+		block := f.blocks[len(f.blocks)-1]
+		f.blocks = f.blocks[:len(f.blocks)-1]
+		f.bodyIndents = block.bodyIndents
+		f.ifIndents = block.ifIndents
+		f.parens = block.parens
+		f.write(token, false)
+		if block.isFunction {
+			break
+		}
+		f.endStatement()
+		if f.check(ELSE) || f.check(CATCH) || f.check(FINALLY) {
+			// Keep the "else", "catch" and "finally" on the same line as the
+			// closing brace
+			f.pendingNewline = false
+		}
+	case LEFT_PAREN:
+		previous := f.previous()
+		keyword := LEFT_PAREN
+		if previous != nil && (previous.tokenType == IF || previous.tokenType == WHILE ||
+			previous.tokenType == FOR || previous.tokenType == FUN) {
+			keyword = previous.tokenType
+		}
+		f.write(token, f.spaceBeforeParen(previous))
+		f.parens = append(f.parens, keyword)
+	case RIGHT_PAREN:
+		f.write(token, false)
+		if len(f.parens) == 0 {
+			break
+		}
+		keyword := f.parens[len(f.parens)-1]
+		f.parens = f.parens[:len(f.parens)-1]
+		if keyword == FUN {
+			f.functionBody = true
+		} else if keyword != LEFT_PAREN {
+			f.startBody(keyword == IF)
+		}
+	case SEMICOLON:
+		f.write(token, false)
+		if len(f.parens) == 0 {
+			f.endStatement()
+		}
+	case COMMA, DOT:
+		f.write(token, false)
+	case ELSE:
+		f.write(token, true)
+		if !f.check(IF) {
+			f.startBody(false)
+		}
+	default:
+		previous := f.previous()
+		withSpace := previous != nil && previous.tokenType != DOT && previous.tokenType != LEFT_PAREN &&
+			previous.tokenType != ELLIPSIS
+		f.write(token, withSpace && !f.isUnaryOperator(f.previousIndex(f.current-1)))
+	}
+}
+
+func (f *Formatter) formatComment(token *Token) {
+	if f.lastLine == token.line && f.line.Len() > 0 {
+		// Comment at the end of a line
+		f.line.WriteString(" " + token.lexeme)
+		f.pendingNewline = true
+		return
+	}
+	f.newline()
+	f.blankLine(token)
+	f.write(token, false)
+	f.pendingNewline = true
+}
+
+// The body of a control flow statement starts. Without braces the body is a
+// single statement written on its own line with an additional indentation.
+func (f *Formatter) startBody(isIf bool) {
+	if f.check(LEFT_BRACE) {
+		return
+	}
+	if isIf {
+		f.ifIndents = append(f.ifIndents, f.indent)
+	}
+	f.bodyIndents += 1
+	f.indent += 1
+	f.pendingNewline = true
+}
+
+func (f *Formatter) endStatement() {
+	f.pendingNewline = true
+	if f.check(ELSE) && len(f.ifIndents) > 0 {
+		// The else branch is aligned with the closest if statement
+		ifIndent := f.ifIndents[len(f.ifIndents)-1]
+		f.ifIndents = f.ifIndents[:len(f.ifIndents)-1]
+		f.bodyIndents -= f.indent - ifIndent
+		f.indent = ifIndent
+		return
+	}
+	f.indent -= f.bodyIndents
+	f.bodyIndents = 0
+	f.ifIndents = nil
+}
+
+// Write the token in the current line preceded by a space if requested
+func (f *Formatter) write(token *Token, withSpace bool) {
+	if f.line.Len() == 0 {
+		f.line.WriteString(strings.Repeat(formatterIndentation, f.indent))
+	} else if withSpace {
+		f.line.WriteString(" ")
+	}
+	f.line.WriteString(token.lexeme)
+	f.lastLine = token.line
+}
+
+func (f *Formatter) newline() {
+	if f.line.Len() == 0 {
+		return
+	}
+	f.lines = append(f.lines, f.line.String())
+	f.line.Reset()
+}
+
+// Keep one empty line if the token was separated from the previous one by
+// empty lines, except at the start and at the end of a block
+func (f *Formatter) blankLine(token *Token) {
+	startLine := token.line - strings.Count(token.lexeme, "\n")
+	if len(f.lines) == 0 || startLine-f.lastLine < 2 || token.tokenType == RIGHT_BRACE {
+		return
+	}
+	if strings.HasSuffix(f.lines[len(f.lines)-1], "{") {
+		return
+	}
+	f.lines = append(f.lines, "")
+}
+
+func (f *Formatter) spaceBeforeParen(previous *Token) bool {
+	if previous == nil {
+		return false
+	}
+	switch previous.tokenType {
+	case IDENTIFIER, RIGHT_PAREN, LEFT_PAREN, DOT:
+		// Call or nested parenthesis
+		return false
+	}
+	return !f.isUnaryOperator(f.previousIndex(f.current - 1))
+}
+
+// Check if the token at the given index is an unary operator
+func (f *Formatter) isUnaryOperator(index int) bool {
+	token := f.tokenAt(index)
+	if token == nil {
+		return false
+	}
+	switch token.tokenType {
+	case BANG:
+		return true
+	case MINUS:
+		previous := f.tokenAt(f.previousIndex(index))
+		if previous == nil {
+			return true
+		}
+		switch previous.tokenType {
+		case IDENTIFIER, NUMBER, STRING, RIGHT_PAREN, TRUE, FALSE, NIL, THIS, SUPER:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func (f *Formatter) tokenAt(index int) *Token {
+	if index < 0 {
+		return nil
+	}
+	return f.tokens[index]
+}
+
+// Get the index of the last token before the given index ignoring the comments
+func (f *Formatter) previousIndex(index int) int {
+	index -= 1
+	for index >= 0 && f.tokens[index].tokenType == COMMENT {
+		index -= 1
+	}
+	return index
+}
+
+// Get the token before the current one ignoring the comments
+func (f *Formatter) previous() *Token {
+	return f.tokenAt(f.previousIndex(f.current - 1))
+}
+
+// Check the type of the next token ignoring the comments
+func (f *Formatter) check(tokenType TokenType) bool {
+	for _, token := range f.tokens[f.current:] {
+		if token.tokenType != COMMENT {
+			return token.tokenType == tokenType
+		}
+	}
+	return false
+}
+
+func (f *Formatter) next() *Token {
+	f.current += 1
+	return f.tokens[f.current-1]
+}
+
+func (f *Formatter) isAtEnd() bool {
+	return f.tokens[f.current].tokenType == EOF
+}
@@ -0,0 +1,91 @@
+package golox
+
+import "testing"
+
+// The formatted source has the same AST as the original source and is not
+// changed by a second formatting
+func TestFormatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		formatted string
+	}{
+		{
+			name:      "spaces around the operators",
+			source:    "var a=1;var b=a*2+-3;print a==b;",
+			formatted: "var a = 1;\nvar b = a * 2 + -3;\nprint a == b;\n",
+		},
+		{
+			name:      "if statements",
+			source:    "if(a>1){print a;}else if(a<0)print -a;else{print 0;}",
+			formatted: "if (a > 1) {\n  print a;\n} else if (a < 0)\n  print -a;\nelse {\n  print 0;\n}\n",
+		},
+		{
+			name:      "nested bodies without braces",
+			source:    "if (a) if (b) print 1; else print 2;",
+			formatted: "if (a)\n  if (b)\n    print 1;\n  else\n    print 2;\n",
+		},
+		{
+			name:      "loops",
+			source:    "while(i<10){i=i+1;}\nfor(var i=0;i<3;i=i+1)print i;",
+			formatted: "while (i < 10) {\n  i = i + 1;\n}\nfor (var i = 0; i < 3; i = i + 1)\n  print i;\n",
+		},
+		{
+			name:      "comments and blank lines",
+			source:    "// header\nvar a = 1; // trailing\n\n\n{\n// inside\nprint a;\n}\n",
+			formatted: "// header\nvar a = 1; // trailing\n\n{\n  // inside\n  print a;\n}\n",
+		},
+		{
+			name:      "functions",
+			source:    "var f=fun(a,b=2,...rest){return a+b;};\nvar g=(x)=>x*2;\nprint f(1,2,3)+g(4);",
+			formatted: "var f = fun (a, b = 2, ...rest) {\n  return a + b;\n};\nvar g = (x) => x * 2;\nprint f(1, 2, 3) + g(4);\n",
+		},
+		{
+			name:      "exceptions",
+			source:    "try{throw \"x\";}catch(e){print e.message;}finally{print \"done\";}",
+			formatted: "try {\n  throw \"x\";\n} catch (e) {\n  print e.message;\n} finally {\n  print \"done\";\n}\n",
+		},
+		{
+			name:      "imports",
+			source:    "import \"lib.golox\" as lib;\nfrom \"lib.golox\" import a,b;\nprint lib.a;",
+			formatted: "import \"lib.golox\" as lib;\nfrom \"lib.golox\" import a, b;\nprint lib.a;\n",
+		},
+		{
+			name:      "unary and grouping",
+			source:    "print !true and (1+2)*3 % 2;",
+			formatted: "print !true and (1 + 2) * 3 % 2;\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatted, err := NewFormatter().Format(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted != test.formatted {
+				t.Errorf("%q is formatted as %q, want %q", test.source, formatted, test.formatted)
+			}
+			again, err := NewFormatter().Format(formatted)
+			if err != nil || again != formatted {
+				t.Errorf("%q is formatted again as %q: %v", formatted, again, err)
+			}
+			original, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reformatted, err := NewGoLox().parse(formatted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if NewAstPrinter().Print(original) != NewAstPrinter().Print(reformatted) {
+				t.Errorf("the formatting of %q changes its AST", test.source)
+			}
+		})
+	}
+}
+
+func TestFormatterSyntaxError(t *testing.T) {
+	if _, err := NewFormatter().Format("print 1 +;"); err == nil {
+		t.Errorf("a source with a syntax error is formatted")
+	}
+}
@@ -0,0 +1,83 @@
+package golox
+
+// LoxFunction is a function created by a function expression, it closes over
+// the environment where the expression is evaluated
+type LoxFunction struct {
+	declaration *Function[any]
+	closure     *Environment
+	// Module where the function is declared, nil for the main script
+	module *LoxModule
+}
+
+func NewLoxFunction(declaration *Function[any], closure *Environment, module *LoxModule) *LoxFunction {
+	return &LoxFunction{declaration: declaration, closure: closure, module: module}
+}
+
+// The parameters with a default value are optional, the rest parameter takes
+// any number of arguments
+func (f *LoxFunction) arity() Arity {
+	required := 0
+	for _, value := range f.declaration.defaults {
+		if value == nil {
+			required += 1
+		}
+	}
+	if f.declaration.rest != nil {
+		return Arity{min: required, max: variadic}
+	}
+	return Arity{min: required, max: len(f.declaration.params)}
+}
+
+func (f *LoxFunction) call(interp *Interpreter, args []any) (any, error) {
+	environment := NewEnvironmentWithEnclosing(f.closure)
+	currentEnv, currentModule := interp.environment, interp.module
+	interp.environment, interp.module = environment, f.module
+	defer func() { interp.environment, interp.module = currentEnv, currentModule }()
+	for i, param := range f.declaration.params {
+		if i < len(args) {
+			environment.define(param.lexeme, args[i])
+			continue
+		}
+		// The default values are evaluated on each call and can use the
+		// previous parameters
+		value, err := interp.evaluate(f.declaration.defaults[i])
+		if err != nil {
+			return nil, err
+		}
+		environment.define(param.lexeme, value)
+	}
+	if f.declaration.rest != nil {
+		rest := []any{}
+		if len(args) > len(f.declaration.params) {
+			rest = append(rest, args[len(f.declaration.params):]...)
+		}
+		environment.define(f.declaration.rest.lexeme, NewLoxList(rest))
+	}
+	for _, statement := range f.declaration.body {
+		err := interp.checkContext()
+		if err != nil {
+			return nil, err
+		}
+		_, err = interp.execute(statement)
+		if returnValue, ok := err.(*returnSignal); ok {
+			return returnValue.value, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (f *LoxFunction) String() string {
+	return "<function>"
+}
+
+// returnSignal unwinds the statements of a function up to its call when a
+// return statement is executed
+type returnSignal struct {
+	value any
+}
+
+func (r *returnSignal) Error() string {
+	return "return outside of a function"
+}
@@ -2,8 +2,10 @@ package golox
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -11,68 +13,271 @@ import (
 
 type GoLox struct {
 	hadRuntimeError bool
+	optimize        bool
+	// Path of the pprof profile written after running a file, no profiling if
+	// empty
+	profilePath string
+	// Path of the LCOV file written after running a file, no coverage if empty
+	coveragePath string
+	options      InterpreterOptions
+	// Arguments given to the scripts run
+	arguments []string
 }
 
 func NewGoLox() *GoLox {
-	return &GoLox{hadRuntimeError: false}
+	return &GoLox{hadRuntimeError: false, optimize: false, options: DefaultInterpreterOptions()}
+}
+
+// Set the arguments given to the scripts, they are read with the native args
+func (lox *GoLox) SetArguments(arguments []string) {
+	lox.arguments = arguments
+}
+
+// Set the limits and the capabilities of the scripts run
+func (lox *GoLox) SetInterpreterOptions(options InterpreterOptions) {
+	lox.options = options
+}
+
+// Enable the optimization pass between the parsing and the interpretation
+func (lox *GoLox) EnableOptimizer() {
+	lox.optimize = true
+}
+
+// Profile the execution of the files, a report is written on the standard
+// error and the profile in the format of pprof is written in the given path
+func (lox *GoLox) EnableProfiler(profilePath string) {
+	lox.profilePath = profilePath
+}
+
+// Track the statements and branches executed in the files, a summary is
+// written on the standard error and the coverage in the LCOV format is written
+// in the given path
+func (lox *GoLox) EnableCoverage(coveragePath string) {
+	lox.coveragePath = coveragePath
 }
 
 func (lox *GoLox) RunFile(path string) {
-	interpreter := NewInterpreter()
+	lox.RunFileContext(context.Background(), path)
+}
+
+// Run the file until the end or until the context is done
+func (lox *GoLox) RunFileContext(ctx context.Context, path string) {
+	interpreter := NewInterpreterWithOptions(lox.options)
 	bytes, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatal("ERROR: ", err)
 	}
-	lox.run(string(bytes), interpreter, false)
+	interpreter.setScriptPath(path)
+	interpreter.SetArguments(lox.arguments)
+	statements, err := lox.parse(string(bytes))
+	if err == nil {
+		var profiler *Profiler
+		var coverage *Coverage
+		if lox.profilePath != "" {
+			profiler = NewProfiler(path)
+			interpreter.SetExecutionHook(profiler)
+		} else if lox.coveragePath != "" {
+			coverage = NewCoverage(path, statements)
+			interpreter.SetExecutionHook(coverage)
+		}
+		_, err = interpreter.interpret(ctx, statements, false)
+		if profiler != nil {
+			lox.writeProfile(profiler)
+		}
+		if coverage != nil {
+			lox.writeCoverage(coverage)
+		}
+	}
+	if err != nil {
+		var exitErr *ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Println(err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// Get the status of the process ending with the error of a script: the code
+// given to exit, 65 for a syntax error and 70 for an error of the execution
+func exitCode(err error) int {
+	var exitErr *ExitError
+	var syntaxErr *SyntaxError
+	var runtimeErr *RuntimeError
+	var limitErr limitError
+	var cancellationErr *CancellationError
+	var throwErr *ThrowError
+	if err == nil {
+		return 0
+	} else if errors.As(err, &exitErr) {
+		return exitErr.Code()
+	} else if errors.As(err, &syntaxErr) {
+		return 65
+	} else if errors.As(err, &runtimeErr) || errors.As(err, &limitErr) ||
+		errors.As(err, &cancellationErr) || errors.As(err, &throwErr) {
+		return 70
+	}
+	return 1
+}
+
+// Run the script in the debugger reading the commands on the standard input
+func (lox *GoLox) DebugFile(path string) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+	source := string(bytes)
+	statements, err := lox.parse(source)
 	if err != nil {
 		fmt.Println(err)
-		var syntaxErr *SyntaxError
-		var runtimeErr *RuntimeError
-		if errors.As(err, &syntaxErr) {
+		os.Exit(65)
+	}
+	interpreter := NewInterpreterWithOptions(lox.options)
+	interpreter.setScriptPath(path)
+	reader := bufio.NewReader(os.Stdin)
+	// The natives reading the input share the reader of the console
+	interpreter.SetInput(reader)
+	interpreter.SetExecutionHook(NewDebugger(newDebugConsole(source, reader, os.Stdout)))
+	_, err = interpreter.interpret(context.Background(), statements, false)
+	var exitErr *ExitError
+	if errors.Is(err, errDebuggerQuit) {
+		return
+	} else if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code())
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(70)
+	}
+}
+
+// Format the files in place. With check the files are not modified but the
+// program exits with an error if a file is not formatted.
+func (lox *GoLox) FormatFiles(paths []string, check bool) {
+	isFormatted := true
+	for _, path := range paths {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("ERROR: ", err)
+		}
+		source := string(bytes)
+		formatted, err := NewFormatter().Format(source)
+		if err != nil {
+			fmt.Println(path, err)
+			os.Exit(65)
+		}
+		if formatted == source {
+			continue
+		}
+		if check {
+			fmt.Println(path)
+			isFormatted = false
+		} else if err = os.WriteFile(path, []byte(formatted), 0644); err != nil {
+			log.Fatal("ERROR: ", err)
+		}
+	}
+	if !isFormatted {
+		os.Exit(1)
+	}
+}
+
+// Print the warnings of the linter for the files. The program exits with an
+// error if there is a warning.
+func (lox *GoLox) LintFiles(paths []string) {
+	hasWarnings := false
+	for _, path := range paths {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("ERROR: ", err)
+		}
+		warnings, err := NewLinter().Lint(string(bytes))
+		if err != nil {
+			fmt.Println(path, err)
 			os.Exit(65)
-		} else if errors.As(err, &runtimeErr) {
-			os.Exit(70)
-		} else {
-			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Println(path, warning)
+			hasWarnings = true
 		}
 	}
+	if hasWarnings {
+		os.Exit(1)
+	}
+}
+
+// Run the language server on the standard input and output
+func (lox *GoLox) RunLanguageServer() {
+	err := NewLanguageServer(os.Stdin, os.Stdout).Serve()
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+}
+
+// Run the debug adapter on the standard input and output, the scripts are
+// launched with the options and the arguments of lox
+func (lox *GoLox) RunDebugAdapter() {
+	err := NewDebugAdapterWithGoLox(lox, os.Stdin, os.Stdout).Serve()
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+}
+
+func (lox *GoLox) writeProfile(profiler *Profiler) {
+	profiler.WriteReport(os.Stderr)
+	file, err := os.Create(lox.profilePath)
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+	defer file.Close()
+	err = profiler.WritePprof(file)
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+}
+
+func (lox *GoLox) writeCoverage(coverage *Coverage) {
+	coverage.WriteSummary(os.Stderr)
+	file, err := os.Create(lox.coveragePath)
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+	defer file.Close()
+	coverage.WriteLcov(file)
 }
 
 func (lox *GoLox) RunPrompt() {
-	interpreter := NewInterpreter()
+	interpreter := NewInterpreterWithOptions(lox.options)
 	reader := bufio.NewReader(os.Stdin)
+	// The natives reading the input share the reader of the prompt
+	interpreter.SetInput(reader)
 	for {
 		fmt.Print("> ")
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			log.Println(err)
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			log.Fatal("ERROR: ", readErr)
 		}
-		err = lox.run(line, interpreter, true)
-		if err != nil {
+		err := lox.run(line, interpreter, true)
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code())
+		} else if err != nil {
 			fmt.Println(err)
 		}
+		if readErr != nil {
+			// End of the input
+			fmt.Println()
+			return
+		}
 	}
 }
 
 func (lox *GoLox) run(source string, interpreter *Interpreter, isRepl bool) error {
-	// Find the tokens
-	scanner := NewScanner(source, 100)
-	tokens, err := scanner.scanTokens()
-	if err != nil {
-		// fmt.Print(err)
-		return err
-	}
-	// Parse the tokens
-	parser := NewParser[any](len(tokens))
-	parser.tokens = append(parser.tokens, tokens...)
-	statements, err := parser.Parse()
+	statements, err := lox.parse(source)
 	if err != nil {
 		return err
 	}
 	// Print the AST
 	// fmt.Println(NewAstPrinter().Print(statements))
 	// Interpret the expression
-	values, err := interpreter.interpret(statements, isRepl)
+	values, err := interpreter.interpret(context.Background(), statements, isRepl)
 	if err != nil {
 		return err
 	}
@@ -81,3 +286,28 @@ func (lox *GoLox) run(source string, interpreter *Interpreter, isRepl bool) erro
 	}
 	return nil
 }
+
+func (lox *GoLox) parse(source string) ([]Stmt[any], error) {
+	// Find the tokens
+	scanner := NewScanner(source, 100)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		// fmt.Print(err)
+		return nil, err
+	}
+	// Parse the tokens
+	parser := NewParser[any](len(tokens))
+	parser.tokens = append(parser.tokens, tokens...)
+	statements, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+	// Optimize the AST
+	if lox.optimize {
+		statements, err = NewOptimizer().Optimize(statements)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,40 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		code   int
+	}{
+		{name: "no error", source: "print 1;", code: 0},
+		{name: "scanner error", source: "print \"abc;", code: 65},
+		{name: "parser error", source: "print 1 +;", code: 65},
+		{name: "runtime error", source: "print -nil;", code: 70},
+		{name: "uncaught throw", source: "throw 1;", code: 70},
+		{name: "exit", source: "exit(3);", code: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lox := NewGoLox()
+			statements, err := lox.parse(test.source)
+			if err == nil {
+				interpreter := NewInterpreter()
+				interpreter.SetOutput(io.Discard)
+				_, err = interpreter.interpret(context.Background(), statements, false)
+			}
+			if code := exitCode(err); code != test.code {
+				t.Errorf("%q exits with %d, want %d: %v", test.source, code, test.code, err)
+			}
+		})
+	}
+	if code := exitCode(errors.New("other")); code != 1 {
+		t.Errorf("another error exits with %d", code)
+	}
+}
@@ -1,7 +1,45 @@
 package golox
 
+import "fmt"
+
 type GoLoxCallable interface {
-	arity() int
+	arity() Arity
 	call(*Interpreter, []any) (any, error)
 	String() string
 }
+
+// Maximum of the arity of a callable accepting any number of arguments
+const variadic = -1
+
+// Arity is the range of the numbers of arguments accepted by a callable
+type Arity struct {
+	min int
+	// The maximum number of arguments or variadic
+	max int
+}
+
+func exactArity(count int) Arity {
+	return Arity{min: count, max: count}
+}
+
+// Check if a callable with the arity can be called with count arguments
+func (a Arity) accepts(count int) bool {
+	return count >= a.min && (a.max == variadic || count <= a.max)
+}
+
+func (a Arity) String() string {
+	if a.max == variadic {
+		return fmt.Sprintf("at least %s", countArguments(a.min))
+	} else if a.min == a.max {
+		return countArguments(a.min)
+	}
+	return fmt.Sprintf("%d to %d arguments", a.min, a.max)
+}
+
+// Get the number of arguments with the noun in the singular for 1
+func countArguments(count int) string {
+	if count == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", count)
+}
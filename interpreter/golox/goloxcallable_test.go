@@ -0,0 +1,115 @@
+package golox
+
+import "testing"
+
+func TestArityString(t *testing.T) {
+	tests := []struct {
+		arity Arity
+		want  string
+	}{
+		{exactArity(0), "0 arguments"},
+		{exactArity(1), "1 argument"},
+		{exactArity(2), "2 arguments"},
+		{Arity{min: 0, max: 1}, "0 to 1 arguments"},
+		{Arity{min: 1, max: 3}, "1 to 3 arguments"},
+		{Arity{min: 0, max: variadic}, "at least 0 arguments"},
+		{Arity{min: 1, max: variadic}, "at least 1 argument"},
+		{Arity{min: 2, max: variadic}, "at least 2 arguments"},
+	}
+	for _, test := range tests {
+		if got := test.arity.String(); got != test.want {
+			t.Errorf("%+v gives %q, want %q", test.arity, got, test.want)
+		}
+	}
+}
+
+// The calls with a number of arguments outside of the arity of the callable
+// fail before calling it
+func TestArityCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Error of the script, no error if empty
+		err string
+	}{
+		{
+			name:   "native without arguments",
+			source: "print readFile();",
+			err:    "[line 1] RUNTIME ERROR: expected 1 argument but got 0",
+		},
+		{
+			name:   "native with too many arguments",
+			source: "print len(\"a\", \"b\");",
+			err:    "[line 1] RUNTIME ERROR: expected 1 argument but got 2",
+		},
+		{
+			name:   "native without parameters",
+			source: "print clock(1);",
+			err:    "[line 1] RUNTIME ERROR: expected 0 arguments but got 1",
+		},
+		{
+			name:   "optional argument of a native",
+			source: "print substr(\"abc\", 1);\nprint substr(\"abc\", 1, 2);",
+			output: "bc\nb\n",
+		},
+		{
+			name:   "missing argument of a native with an optional argument",
+			source: "print substr(\"abc\");",
+			err:    "[line 1] RUNTIME ERROR: expected 2 to 3 arguments but got 1",
+		},
+		{
+			name:   "extra argument of a native with an optional argument",
+			source: "print substr(\"abc\", 0, 1, 2);",
+			err:    "[line 1] RUNTIME ERROR: expected 2 to 3 arguments but got 4",
+		},
+		{
+			name:   "variadic native",
+			source: "print max(1);\nprint max(1, 5, 3, 2);",
+			output: "1.000000\n5.000000\n",
+		},
+		{
+			name:   "variadic native without arguments",
+			source: "print min();",
+			err:    "[line 1] RUNTIME ERROR: expected at least 1 argument but got 0",
+		},
+		{
+			name:   "missing argument of a function",
+			source: "var f = fun (a, b) { return a; };\nprint f(1);",
+			err:    "[line 2] RUNTIME ERROR: expected 2 arguments but got 1",
+		},
+		{
+			name:   "default values",
+			source: "var f = fun (a, b = 2) { return a + b; };\nprint f(1);\nprint f(1, 3);\nprint f();",
+			output: "3.000000\n4.000000\n",
+			err:    "[line 4] RUNTIME ERROR: expected 1 to 2 arguments but got 0",
+		},
+		{
+			name:   "default value using a previous parameter",
+			source: "var f = fun (a = 1, b = a + 1) { return b; };\nprint f();\nprint f(2);",
+			output: "2.000000\n3.000000\n",
+		},
+		{
+			name:   "extra argument of a function with default values",
+			source: "var f = fun (a, b = 2) { return a + b; };\nprint f(1, 2, 3);",
+			err:    "[line 2] RUNTIME ERROR: expected 1 to 2 arguments but got 3",
+		},
+		{
+			name:   "rest parameter",
+			source: "var f = fun (a, ...rest) { return rest; };\nprint f(1);\nprint f(1, 2, 3);\nprint f();",
+			output: "[]\n[2.000000, 3.000000]\n",
+			err:    "[line 4] RUNTIME ERROR: expected at least 1 argument but got 0",
+		},
+		{
+			name:   "callback of a native",
+			source: "print map(range(2), fun (a, b = 1) { return a + b; });\nprint map(range(2), fun (a, b) { return a; });",
+			output: "[1.000000, 2.000000]\n",
+			err:    "[line 2] RUNTIME ERROR: map: the function given as argument expects 2 arguments but got 1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkTestScript(t, test.source, test.output, test.err)
+		})
+	}
+}
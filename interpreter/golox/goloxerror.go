@@ -1,6 +1,9 @@
 package golox
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type SyntaxError struct {
 	line    int
@@ -31,15 +34,194 @@ func (e *SyntaxErrors) Error() string {
 	return message
 }
 
+// Give the errors to errors.Is and errors.As, a SyntaxErrors is then found as
+// a SyntaxError
+func (e *SyntaxErrors) Unwrap() []error {
+	errs := make([]error, len(e.errors))
+	for i, err := range e.errors {
+		errs[i] = err
+	}
+	return errs
+}
+
 type RuntimeError struct {
 	token   *Token
 	message string
+	// Kind of the error given to the scripts catching it
+	kind string
 }
 
 func NewRuntimeError(token *Token, message string) *RuntimeError {
-	return &RuntimeError{token, message}
+	return &RuntimeError{token, message, "RuntimeError"}
+}
+
+// Create the error of a native function called at the given token
+func NewNativeError(token *Token, message string) *RuntimeError {
+	return &RuntimeError{token, message, "NativeError"}
+}
+
+// Create the error of a bug of the interpreter, e.g. a panic, at the line of
+// the last statement executed
+func NewInternalError(line int, message string) *RuntimeError {
+	token := &Token{line: line}
+	return &RuntimeError{token, "internal error: " + message, "InternalError"}
 }
 
 func (e *RuntimeError) Error() string {
 	return fmt.Sprintf("[line %d] RUNTIME ERROR: %s", e.token.line, e.message)
 }
+
+// limitError is implemented by the errors stopping a script exceeding the
+// limits or the capabilities given by the InterpreterOptions
+type limitError interface {
+	error
+	// Set the line of the error if it is unknown
+	setLine(line int)
+}
+
+type limitErrorBase struct {
+	line    int
+	message string
+}
+
+func (e *limitErrorBase) Error() string {
+	return fmt.Sprintf("[line %d] LIMIT ERROR: %s", e.line, e.message)
+}
+
+func (e *limitErrorBase) setLine(line int) {
+	if e.line == 0 {
+		e.line = line
+	}
+}
+
+type StepLimitError struct {
+	limitErrorBase
+}
+
+func NewStepLimitError(line int, maxSteps int) *StepLimitError {
+	message := fmt.Sprintf("more than %d statements executed", maxSteps)
+	return &StepLimitError{limitErrorBase{line, message}}
+}
+
+type TimeoutError struct {
+	limitErrorBase
+}
+
+func NewTimeoutError(line int, timeout time.Duration) *TimeoutError {
+	message := fmt.Sprintf("execution longer than %s", timeout)
+	return &TimeoutError{limitErrorBase{line, message}}
+}
+
+type CallDepthError struct {
+	limitErrorBase
+}
+
+func NewCallDepthError(line int, maxCallDepth int) *CallDepthError {
+	message := fmt.Sprintf("more than %d nested calls", maxCallDepth)
+	return &CallDepthError{limitErrorBase{line, message}}
+}
+
+// Create the error of a recursion too deep for the interpreter. Unlike the
+// CallDepthError of the MaxCallDepth option it is a runtime error, the scripts
+// can catch it.
+func NewStackOverflowError(line int) *RuntimeError {
+	token := &Token{line: line}
+	message := fmt.Sprintf("stack overflow, more than %d nested calls", stackOverflowDepth)
+	return &RuntimeError{token, message, "RuntimeError"}
+}
+
+type SizeLimitError struct {
+	limitErrorBase
+}
+
+func NewSizeLimitError(line int, size int, maxSize int) *SizeLimitError {
+	message := fmt.Sprintf("size %d greater than the maximum %d", size, maxSize)
+	return &SizeLimitError{limitErrorBase{line, message}}
+}
+
+// AccessError is returned when a script accesses a resource it is not allowed
+// to, e.g. a file outside of the root of the file system natives
+type AccessError struct {
+	limitErrorBase
+}
+
+func NewAccessError(line int, message string) *AccessError {
+	return &AccessError{limitErrorBase{line, message}}
+}
+
+// CancellationError is returned when the host cancels the context of the
+// interpretation
+type CancellationError struct {
+	line  int
+	cause error
+}
+
+func NewCancellationError(line int, cause error) *CancellationError {
+	return &CancellationError{line: line, cause: cause}
+}
+
+func (e *CancellationError) Error() string {
+	return fmt.Sprintf("[line %d] CANCELLED: %s", e.line, e.cause)
+}
+
+func (e *CancellationError) Unwrap() error {
+	return e.cause
+}
+
+// ThrowError is returned by a throw statement until a try statement catches
+// the thrown value
+type ThrowError struct {
+	line  int
+	value any
+	// Value as written by print
+	message string
+}
+
+func NewThrowError(line int, value any, message string) *ThrowError {
+	return &ThrowError{line: line, value: value, message: message}
+}
+
+func (e *ThrowError) Error() string {
+	if loxError, ok := e.value.(*LoxError); ok {
+		// An error caught and thrown again is reported as the original one
+		return loxError.description
+	}
+	return fmt.Sprintf("[line %d] UNCAUGHT ERROR: %s", e.line, e.message)
+}
+
+// ExitError is returned when a script calls the native exit, it stops the
+// interpretation and gives the status the process should exit with
+type ExitError struct {
+	code int
+}
+
+func NewExitError(code int) *ExitError {
+	return &ExitError{code: code}
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit with status %d", e.code)
+}
+
+func (e *ExitError) Code() int {
+	return e.code
+}
+
+// ModuleError is an error raised while executing an imported module, the line
+// of the error is a line of the module
+type ModuleError struct {
+	path string
+	err  error
+}
+
+func NewModuleError(path string, err error) *ModuleError {
+	return &ModuleError{path: path, err: err}
+}
+
+func (e *ModuleError) Error() string {
+	return fmt.Sprintf("%s (in the module '%s')", e.err, e.path)
+}
+
+func (e *ModuleError) Unwrap() error {
+	return e.err
+}
@@ -1,28 +1,215 @@
 package golox
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
 )
 
 type Interpreter struct {
 	environment *Environment
 	globals     *Environment
+	// Number of statements being executed, i.e. the nesting depth of the
+	// statement executed
+	depth int
+	hook  ExecutionHook
+	// Output of the print statements
+	stdout io.Writer
+	// Input read by the natives, the standard input is read if nil
+	stdin *bufio.Reader
+	// Arguments given to the script on the command line
+	arguments []string
+	options   InterpreterOptions
+	// Number of statements executed since the start of the interpretation
+	steps int
+	// Line of the last statement executed
+	line int
+	// Number of calls in progress
+	callDepth int
+	// Native functions, enclosing the global environments of the modules
+	natives *Environment
+	// Modules imported by path, nil while the module is being loaded
+	modules map[string]*LoxModule
+	// Paths of the modules being loaded, from the first imported
+	importing []string
+	// Directory of the script being executed, base of the paths of the
+	// imports
+	directory string
+	// Module whose code is executed, nil for the code of the main script
+	module *LoxModule
+	// Generator of the random numbers, seeded by the scripts to get the same
+	// numbers on each run
+	random *rand.Rand
+	// Context of the interpretation, done when the host cancels it or when the
+	// timeout expires
+	ctx context.Context
+}
+
+// InterpreterOptions limits the resources used by a script and the
+// capabilities given to it, e.g. to run untrusted scripts. A zero limit means
+// no limit.
+type InterpreterOptions struct {
+	// Maximum number of statements executed by an interpretation
+	MaxSteps int
+	// Maximum duration of an interpretation
+	Timeout time.Duration
+	// Maximum number of nested calls
+	MaxCallDepth int
+	// Maximum length of the strings and collections created by the script
+	MaxSize int
+	// Register the natives accessing the file system
+	AllowFileSystem bool
+	// Register the natives reading and writing the environment variables
+	AllowEnvironment bool
+	// Directory outside of which the file system natives cannot access the
+	// files, no restriction if empty
+	FileSystemRoot string
+}
+
+// Get the options of a trusted script: no limits and all the capabilities
+func DefaultInterpreterOptions() InterpreterOptions {
+	return InterpreterOptions{AllowFileSystem: true, AllowEnvironment: true}
+}
+
+// propertyHolder is implemented by the values having properties read with a
+// dot, e.g. the modules
+type propertyHolder interface {
+	get(name *Token) (any, error)
+}
+
+// ExecutionHook is notified by the interpreter before the execution of each
+// statement, e.g. to pause the execution in a debugger. Returning an error
+// stops the interpretation with this error.
+type ExecutionHook interface {
+	beforeExecute(interp *Interpreter, stmt Stmt[any]) error
+}
+
+// CallHook can be implemented by an ExecutionHook to be also notified around
+// each call of a callable, e.g. to measure the time spent in the callables.
+type CallHook interface {
+	beforeCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable) error
+	afterCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable)
+}
+
+// BranchHook can be implemented by an ExecutionHook to be also notified of the
+// branches taken by the if statements and the logical expressions.
+type BranchHook interface {
+	ifBranchTaken(interp *Interpreter, stmt *If[any], isThenBranch bool)
+	logicalBranchTaken(interp *Interpreter, expr *Logical[any], isShortCircuit bool)
 }
 
 func NewInterpreter() *Interpreter {
+	return NewInterpreterWithOptions(DefaultInterpreterOptions())
+}
+
+func NewInterpreterWithOptions(options InterpreterOptions) *Interpreter {
 	globals := NewEnvironment()
-	globals.define("clock", &Clock{})
-	globals.define("readFile", &ReadFile{})
-	return &Interpreter{environment: globals, globals: globals}
+	defineNatives(globals, options)
+	natives := NewEnvironment()
+	defineNatives(natives, options)
+	return &Interpreter{
+		environment: globals,
+		globals:     globals,
+		stdout:      os.Stdout,
+		options:     options,
+		natives:     natives,
+		modules:     make(map[string]*LoxModule),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		ctx:         context.Background(),
+	}
 }
 
-func (interp *Interpreter) interpret(statements []Stmt[any], isRepl bool) ([]string, error) {
+func defineNatives(environment *Environment, options InterpreterOptions) {
+	environment.define("clock", &Clock{})
+	if options.AllowFileSystem {
+		environment.define("readFile", &ReadFile{})
+		defineNativeFunctions(environment, fileNatives...)
+	}
+	defineNativeFunctions(environment, stringNatives...)
+	defineNativeFunctions(environment, mathNatives...)
+	defineNativeFunctions(environment, typeNatives...)
+	defineNativeFunctions(environment, listNatives...)
+	defineNativeFunctions(environment, processNatives...)
+	if options.AllowEnvironment {
+		defineNativeFunctions(environment, environmentNatives...)
+	}
+	defineMathConstants(environment)
+}
+
+func (interp *Interpreter) SetOutput(stdout io.Writer) {
+	interp.stdout = stdout
+}
+
+func (interp *Interpreter) SetInput(stdin io.Reader) {
+	interp.stdin = bufio.NewReader(stdin)
+}
+
+// Get the reader of the input. The standard input is buffered on the first
+// read so the interpreters that never read, e.g. the ones of the optimizer,
+// don't take its bytes.
+func (interp *Interpreter) input() *bufio.Reader {
+	if interp.stdin == nil {
+		interp.stdin = bufio.NewReader(os.Stdin)
+	}
+	return interp.stdin
+}
+
+func (interp *Interpreter) SetArguments(arguments []string) {
+	interp.arguments = arguments
+}
+
+func (interp *Interpreter) SetExecutionHook(hook ExecutionHook) {
+	interp.hook = hook
+}
+
+// Get the hook notified of the execution of the current code. The hooks work
+// on the lines of the main script, they are not notified of the code of the
+// modules, even when a function of a module is called by the main script.
+func (interp *Interpreter) activeHook() ExecutionHook {
+	if interp.module != nil {
+		return nil
+	}
+	return interp.hook
+}
+
+// Cause of the end of the context of an interpretation exceeding the timeout
+var errInterpreterTimeout = errors.New("interpreter timeout")
+
+// Number of nested calls stopping the script whatever its options, before the
+// recursion overflows the stack of Go which cannot be recovered
+const stackOverflowDepth = 10000
+
+// Interpret the statements until the end or until the context is done
+func (interp *Interpreter) interpret(
+	ctx context.Context, statements []Stmt[any], isRepl bool,
+) (values []string, err error) {
+	defer interp.recoverPanic(len(interp.importing), &err)
 	capacity := 0
 	if isRepl {
 		capacity = 50
 	}
-	values := make([]string, 0, capacity)
+	values = make([]string, 0, capacity)
+	interp.steps = 0
+	interp.ctx = ctx
+	if interp.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		interp.ctx, cancel = context.WithTimeoutCause(ctx, interp.options.Timeout, errInterpreterTimeout)
+		defer cancel()
+	}
+	// The expressions evaluated after the interpretation, e.g. by the
+	// optimizer, must not be stopped
+	defer func() { interp.ctx = context.Background() }()
 	for _, stmt := range statements {
+		err := interp.checkContext()
+		if err != nil {
+			return nil, err
+		}
 		value, err := interp.execute(stmt)
 		if err != nil {
 			return nil, err
@@ -34,6 +221,26 @@ func (interp *Interpreter) interpret(statements []Stmt[any], isRepl bool) ([]str
 	return values, nil
 }
 
+// Convert a panic of the interpretation into an internal error so the host
+// does not crash, the state of the interpreter is reset to keep using it,
+// e.g. in the REPL. importing is the number of modules being loaded before the
+// interpretation.
+func (interp *Interpreter) recoverPanic(importing int, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	for _, path := range interp.importing[importing:] {
+		delete(interp.modules, path)
+	}
+	interp.importing = interp.importing[:importing]
+	interp.environment = interp.globals
+	interp.module = nil
+	interp.depth = 0
+	interp.callDepth = 0
+	*err = NewInternalError(interp.line, fmt.Sprint(value))
+}
+
 func (interp *Interpreter) visitVarStmt(stmt *Var[any]) (any, error) {
 	var value any
 	if stmt.initializer != nil {
@@ -52,6 +259,11 @@ func (interp *Interpreter) visitBlockStmt(expr *Block[any]) (any, error) {
 	currentEnv := interp.environment
 	interp.environment = NewEnvironmentWithEnclosing(currentEnv)
 	for _, statement := range expr.statements {
+		err := interp.checkContext()
+		if err != nil {
+			interp.environment = currentEnv
+			return nil, err
+		}
 		value, err := interp.execute(statement)
 		if err != nil {
 			interp.environment = currentEnv
@@ -70,6 +282,10 @@ func (interp *Interpreter) visitExpressionStmt(stmt *Expression[any]) (any, erro
 
 func (interp *Interpreter) visitWhileStmt(stmt *While[any]) (any, error) {
 	for {
+		err := interp.checkContext()
+		if err != nil {
+			return nil, err
+		}
 		value, err := interp.evaluate(stmt.condition)
 		if err != nil {
 			return nil, err
@@ -90,6 +306,9 @@ func (interp *Interpreter) visitIfStmt(stmt *If[any]) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if branchHook, ok := interp.activeHook().(BranchHook); ok {
+		branchHook.ifBranchTaken(interp, stmt, interp.isTruthy(value))
+	}
 	if interp.isTruthy(value) {
 		value, err := interp.execute(stmt.thenBranch)
 		if err != nil {
@@ -106,16 +325,90 @@ func (interp *Interpreter) visitIfStmt(stmt *If[any]) (any, error) {
 	return nil, nil
 }
 
+func (interp *Interpreter) visitImportStmt(stmt *Import[any]) (any, error) {
+	module, err := interp.importModule(stmt.keyword, stmt.path)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.alias != nil {
+		interp.environment.define(stmt.alias.lexeme, module)
+		return nil, nil
+	}
+	for _, name := range stmt.names {
+		value, err := module.get(name)
+		if err != nil {
+			return nil, err
+		}
+		interp.environment.define(name.lexeme, value)
+	}
+	return nil, nil
+}
+
 func (interp *Interpreter) visitPrintStmt(stmt *Print[any]) (any, error) {
 	value, err := interp.evaluate(stmt.expression)
 	if err == nil {
-		fmt.Println(interp.stringify(value))
+		fmt.Fprintln(interp.stdout, interp.stringify(value))
+	}
+	return nil, err
+}
+
+func (interp *Interpreter) visitReturnStmt(stmt *Return[any]) (any, error) {
+	var value any
+	if stmt.value != nil {
+		var err error
+		value, err = interp.evaluate(stmt.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, &returnSignal{value}
+}
+
+func (interp *Interpreter) visitThrowStmt(stmt *Throw[any]) (any, error) {
+	value, err := interp.evaluate(stmt.value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, NewThrowError(stmt.keyword.line, value, interp.stringify(value))
+}
+
+func (interp *Interpreter) visitTryStmt(stmt *Try[any]) (any, error) {
+	_, err := interp.execute(stmt.body)
+	if err != nil && stmt.handler != nil {
+		if loxError, ok := caughtError(err); ok {
+			currentEnv := interp.environment
+			interp.environment = NewEnvironmentWithEnclosing(currentEnv)
+			interp.environment.define(stmt.name.lexeme, loxError)
+			_, err = interp.execute(stmt.handler)
+			interp.environment = currentEnv
+		}
+	}
+	if stmt.finalizer != nil && !stopsScript(err) {
+		_, finalizerErr := interp.execute(stmt.finalizer)
+		if finalizerErr != nil {
+			err = finalizerErr
+		}
 	}
 	return nil, err
 }
 
 func (interp *Interpreter) execute(stmt Stmt[any]) (any, error) {
+	if line := stmtLine(stmt); line != 0 {
+		interp.line = line
+	}
+	interp.steps += 1
+	if interp.options.MaxSteps > 0 && interp.steps > interp.options.MaxSteps {
+		return nil, NewStepLimitError(interp.line, interp.options.MaxSteps)
+	}
+	if hook := interp.activeHook(); hook != nil {
+		err := hook.beforeExecute(interp, stmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	interp.depth += 1
 	value, err := stmt.accept(interp)
+	interp.depth -= 1
 	return value, err
 }
 
@@ -128,14 +421,33 @@ func (interp *Interpreter) visitLogicalExpr(expr *Logical[any]) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	if expr.operator.tokenType == AND && !interp.isTruthy(leftValue) {
-		return leftValue, nil
-	} else if expr.operator.tokenType == OR && interp.isTruthy(leftValue) {
+	isShortCircuit := (expr.operator.tokenType == AND && !interp.isTruthy(leftValue)) ||
+		(expr.operator.tokenType == OR && interp.isTruthy(leftValue))
+	if branchHook, ok := interp.activeHook().(BranchHook); ok {
+		branchHook.logicalBranchTaken(interp, expr, isShortCircuit)
+	}
+	if isShortCircuit {
 		return leftValue, nil
 	}
 	return interp.evaluate(expr.right)
 }
 
+func (interp *Interpreter) visitFunctionExpr(expr *Function[any]) (any, error) {
+	return NewLoxFunction(expr, interp.environment, interp.module), nil
+}
+
+func (interp *Interpreter) visitGetExpr(expr *Get[any]) (any, error) {
+	object, err := interp.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	holder, ok := object.(propertyHolder)
+	if !ok {
+		return nil, NewRuntimeError(expr.name, "only the modules and the errors have properties")
+	}
+	return holder.get(expr.name)
+}
+
 func (interp *Interpreter) visitGroupingExpr(expr *Grouping[any]) (any, error) {
 	return interp.evaluate(expr.expression)
 }
@@ -145,7 +457,10 @@ func (interp *Interpreter) visitVariableExpr(expr *Variable[any]) (any, error) {
 }
 
 func (interp *Interpreter) visitUnaryExpr(expr *Unary[any]) (any, error) {
-	right, _ := interp.evaluate(expr.right)
+	right, err := interp.evaluate(expr.right)
+	if err != nil {
+		return nil, err
+	}
 	switch expr.operator.tokenType {
 	case BANG:
 		return !interp.isTruthy(right), nil
@@ -168,11 +483,11 @@ func (interp *Interpreter) visitCallExpr(expr *Call[any]) (any, error) {
 	if !ok {
 		return nil, NewRuntimeError(expr.paren, fmt.Sprintf("'%s' is not a callable", callee))
 	}
-	if len(expr.arguments) > callable.arity() {
+	if !callable.arity().accepts(len(expr.arguments)) {
 		return nil, NewRuntimeError(
 			expr.paren,
 			fmt.Sprintf(
-				"expected %d arguments but got %d",
+				"expected %s but got %d",
 				callable.arity(),
 				len(expr.arguments),
 			),
@@ -186,16 +501,99 @@ func (interp *Interpreter) visitCallExpr(expr *Call[any]) (any, error) {
 		}
 		args[i] = argValue
 	}
+	err = interp.checkContext()
+	if err != nil {
+		return nil, err
+	}
+	err = interp.checkCallDepth(expr.paren.line)
+	if err != nil {
+		return nil, err
+	}
+	callHook, hasCallHook := interp.activeHook().(CallHook)
+	if hasCallHook {
+		err = callHook.beforeCall(interp, expr, callable)
+		if err != nil {
+			return nil, err
+		}
+	}
+	interp.callDepth += 1
 	value, err := callable.call(interp, args)
+	interp.callDepth -= 1
+	if hasCallHook {
+		callHook.afterCall(interp, expr, callable)
+	}
 	if err != nil {
-		return nil, NewRuntimeError(expr.paren, fmt.Sprintf("%s", err.Error()))
+		return nil, interp.callError(expr, err)
 	}
 	return value, nil
 }
 
+// Get the error to return for the error of a callable. The errors of the
+// interpreter, e.g. raised in a callback of a native, are returned as is so
+// they keep their line, the other errors are errors of the natives.
+func (interp *Interpreter) callError(expr *Call[any], err error) error {
+	if limitErr, ok := err.(limitError); ok {
+		// The natives don't know the line of the call
+		limitErr.setLine(expr.paren.line)
+		return err
+	}
+	var runtimeErr *RuntimeError
+	var throwErr *ThrowError
+	var cancellationErr *CancellationError
+	var exitErr *ExitError
+	var moduleErr *ModuleError
+	if errors.As(err, &runtimeErr) || errors.As(err, &throwErr) || errors.As(err, &cancellationErr) ||
+		errors.As(err, &exitErr) || errors.As(err, &moduleErr) || errors.Is(err, errDebuggerQuit) {
+		return err
+	}
+	return NewNativeError(expr.paren, err.Error())
+}
+
+// Call a callable from a native, e.g. a callback given as argument. The limit
+// errors have no line, it is set to the line of the call of the native.
+func (interp *Interpreter) callCallback(function string, callable GoLoxCallable, args []any) (any, error) {
+	if !callable.arity().accepts(len(args)) {
+		return nil, fmt.Errorf(
+			"%s: the function given as argument expects %s but got %d", function, callable.arity(), len(args),
+		)
+	}
+	err := interp.checkContext()
+	if err != nil {
+		return nil, err
+	}
+	err = interp.checkCallDepth(0)
+	if err != nil {
+		return nil, err
+	}
+	interp.callDepth += 1
+	value, err := callable.call(interp, args)
+	interp.callDepth -= 1
+	return value, err
+}
+
+func (interp *Interpreter) checkCallDepth(line int) error {
+	if interp.options.MaxCallDepth > 0 && interp.callDepth >= interp.options.MaxCallDepth {
+		return NewCallDepthError(line, interp.options.MaxCallDepth)
+	} else if interp.callDepth >= stackOverflowDepth {
+		if line == 0 {
+			// The line of the call of a callback is unknown, the line of
+			// the statement is used
+			line = interp.line
+		}
+		return NewStackOverflowError(line)
+	}
+	return nil
+}
+
 func (interp *Interpreter) visitBinaryExpr(expr *Binary[any]) (any, error) {
-	left, _ := interp.evaluate(expr.left)
-	right, _ := interp.evaluate(expr.right)
+	left, err := interp.evaluate(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := interp.evaluate(expr.right)
+	if err != nil {
+		return nil, err
+	}
 	switch expr.operator.tokenType {
 	case MINUS:
 		leftValue, rightValue, err := interp.checkNumberOperands(expr.operator, left, right)
@@ -215,6 +613,15 @@ func (interp *Interpreter) visitBinaryExpr(expr *Binary[any]) (any, error) {
 			return nil, err
 		}
 		return leftValue * rightValue, nil
+	case PERCENT:
+		leftValue, rightValue, err := interp.checkNumberOperands(expr.operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if rightValue == 0 {
+			return nil, NewRuntimeError(expr.operator, "Modulo by zero")
+		}
+		return floorMod(leftValue, rightValue), nil
 	case GREATER:
 		leftValue, rightValue, err := interp.checkNumberOperands(expr.operator, left, right)
 		if err != nil {
@@ -247,14 +654,18 @@ func (interp *Interpreter) visitBinaryExpr(expr *Binary[any]) (any, error) {
 		}
 		if leftValue, okLeft := left.(string); okLeft {
 			if rightValue, okRight := right.(string); okRight {
+				err := interp.checkSize(expr.operator.line, len(leftValue)+len(rightValue))
+				if err != nil {
+					return nil, err
+				}
 				return leftValue + rightValue, nil
 			}
 		}
 		return nil, NewRuntimeError(expr.operator, "The operands must be two numbers or two strings")
 	case BANG_EQUAL:
-		return left != right, nil
+		return !isEqual(left, right), nil
 	case EQUAL_EQUAL:
-		return left == right, nil
+		return isEqual(left, right), nil
 	}
 	return nil, NewRuntimeError(expr.operator, "The operator is no valid for binary expression")
 }
@@ -307,12 +718,41 @@ func (interp *Interpreter) checkNumberOperand(operator *Token, operand any) (flo
 	return value, nil
 }
 
+// Check that the interpretation has been neither cancelled by the host nor
+// stopped by the timeout
+func (interp *Interpreter) checkContext() error {
+	if interp.ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(interp.ctx)
+	if errors.Is(cause, errInterpreterTimeout) {
+		return NewTimeoutError(interp.line, interp.options.Timeout)
+	}
+	return NewCancellationError(interp.line, cause)
+}
+
+// Check that a string or a collection of the given length can be created
+func (interp *Interpreter) checkSize(line int, size int) error {
+	if interp.options.MaxSize > 0 && size > interp.options.MaxSize {
+		return NewSizeLimitError(line, size, interp.options.MaxSize)
+	}
+	return nil
+}
+
 func (interp *Interpreter) stringify(value any) string {
 	switch value := value.(type) {
+	case nil:
+		return "nil"
 	case bool:
 		return fmt.Sprintf("%t", value)
 	case float64:
 		return fmt.Sprintf("%f", value)
+	case *LoxList:
+		elements := make([]string, len(value.elements))
+		for i, element := range value.elements {
+			elements[i] = interp.stringify(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
 	default:
 		return fmt.Sprintf("%s", value)
 	}
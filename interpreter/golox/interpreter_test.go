@@ -0,0 +1,699 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Interpret a script written in a temporary directory with the given modules
+// and get its output
+func interpretTestScript(t *testing.T, source string, modules map[string]string) (string, error) {
+	directory := t.TempDir()
+	for name, module := range modules {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(module), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	statements, err := NewGoLox().parse(source)
+	if err != nil {
+		t.Fatalf("cannot parse %q: %s", source, err)
+	}
+	interpreter := NewInterpreter()
+	interpreter.setScriptPath(filepath.Join(directory, "script.golox"))
+	output := &strings.Builder{}
+	interpreter.SetOutput(output)
+	_, err = interpreter.interpret(context.Background(), statements, false)
+	return output.String(), err
+}
+
+// Interpret a script and check its output and the text of its error, no error
+// expected if empty
+func checkTestScript(t *testing.T, source string, output string, err string) {
+	t.Helper()
+	actualOutput, actualErr := interpretTestScript(t, source, nil)
+	message := ""
+	if actualErr != nil {
+		message = actualErr.Error()
+	}
+	if actualOutput != output || message != err {
+		t.Errorf("%q printed %q and failed with %q, want %q and %q", source, actualOutput, message, output, err)
+	}
+}
+
+// Get the type, line and message of an error returned by the interpreter
+func describeError(err error) (string, int, string) {
+	var moduleErr *ModuleError
+	var throwErr *ThrowError
+	var runtimeErr *RuntimeError
+	if errors.As(err, &moduleErr) {
+		_, line, message := describeError(moduleErr.err)
+		return "ModuleError", line, message
+	} else if errors.As(err, &throwErr) {
+		return "ThrowError", throwErr.line, throwErr.message
+	} else if errors.As(err, &runtimeErr) {
+		return runtimeErr.kind, runtimeErr.token.line, runtimeErr.message
+	}
+	return "", 0, err.Error()
+}
+
+// The error of an expression or a statement stops the script and is reported
+// at its line, the following expressions are not evaluated
+func TestInterpreterErrors(t *testing.T) {
+	tests := []struct {
+		node    string
+		source  string
+		modules map[string]string
+		kind    string
+		line    int
+		message string
+		output  string
+	}{
+		{
+			node:    "binary left operand",
+			source:  "print undefinedVar + 1;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'undefinedVar'",
+		},
+		{
+			node:    "binary right operand",
+			source:  "var a = 1;\nprint a + missing;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "binary operand before a call",
+			source:  "var f = fun () { print \"called\"; return 1; };\nprint missing + f();",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "binary operator",
+			source:  "print 1 +\n nil;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "The operands must be two numbers or two strings",
+		},
+		{
+			node:    "unary minus",
+			source:  "print 1;\nprint -missing;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+			output:  "1.000000\n",
+		},
+		{
+			node:    "unary not",
+			source:  "print !missing;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "call callee",
+			source:  "var a = 1;\nmissing(a);",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "call argument",
+			source:  "var f = fun (a, b) { print \"called\"; };\nf(1,\n missing);",
+			kind:    "RuntimeError",
+			line:    3,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "call of a native",
+			source:  "print 1;\nprint len(\n  2);",
+			kind:    "NativeError",
+			line:    2,
+			message: "len: the argument 'value' must be a string or a list, got number",
+			output:  "1.000000\n",
+		},
+		{
+			node:    "logical left operand",
+			source:  "print missing and true;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "logical right operand",
+			source:  "print false or\n missing;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "grouping",
+			source:  "print (missing);",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "assign value",
+			source:  "var a;\na = missing;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "assign target",
+			source:  "\nmissing = 1;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "get object",
+			source:  "print missing.name;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "var initializer",
+			source:  "var a = 1;\nvar b = missing;",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "print",
+			source:  "print missing;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "expression statement",
+			source:  "missing;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "block",
+			source:  "{\n  var a = 1;\n  print missing;\n  print a;\n}",
+			kind:    "RuntimeError",
+			line:    3,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "if condition",
+			source:  "if (missing) print 1;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "if branch",
+			source:  "if (false) print 1; else {\n  print missing;\n}",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "while condition",
+			source:  "while (missing) {}",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "while body",
+			source:  "var i = 0;\nwhile (i < 3) {\n  i = i + 1;\n  print missing;\n}",
+			kind:    "RuntimeError",
+			line:    4,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "function default value",
+			source:  "var f = fun (a = missing) { return a; };\nf();",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "return value",
+			source:  "var f = fun () {\n  return missing;\n};\nprint f();",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "throw value",
+			source:  "throw missing;",
+			kind:    "RuntimeError",
+			line:    1,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "throw",
+			source:  "print 1;\nthrow \"boom\";",
+			kind:    "ThrowError",
+			line:    2,
+			message: "boom",
+			output:  "1.000000\n",
+		},
+		{
+			node:    "try handler",
+			source:  "try { throw 1; } catch (e) {\n  print missing;\n}",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "stack overflow",
+			source:  "var f = fun (n) {\n  return f(n + 1);\n};\nf(0);",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "stack overflow, more than 10000 nested calls",
+		},
+		{
+			node:    "stack overflow in a callback",
+			source:  "var f = fun (n) {\n  return map(range(1), f);\n};\nf(0);",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "stack overflow, more than 10000 nested calls",
+		},
+		{
+			node:    "import of a module failing",
+			source:  "import \"module.golox\" as module;",
+			modules: map[string]string{"module.golox": "var a = 1;\nprint missing;\n"},
+			kind:    "ModuleError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			node:    "import of a missing name",
+			source:  "\nfrom \"module.golox\" import a, missing;",
+			modules: map[string]string{"module.golox": "var a = 1;\n"},
+			kind:    "RuntimeError",
+			line:    2,
+			// The message contains the temporary path of the module
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.node, func(t *testing.T) {
+			output, err := interpretTestScript(t, test.source, test.modules)
+			if err == nil {
+				t.Fatalf("%q did not fail", test.source)
+			}
+			kind, line, message := describeError(err)
+			if kind != test.kind || line != test.line || (test.message != "" && message != test.message) {
+				t.Errorf("%q failed with %s at line %d: %q, want %s at line %d: %q",
+					test.source, kind, line, message, test.kind, test.line, test.message)
+			}
+			if output != test.output {
+				t.Errorf("%q printed %q, want %q", test.source, output, test.output)
+			}
+		})
+	}
+}
+
+// Unlike the limit of the options, the stack overflow is a runtime error
+// that the scripts can catch
+func TestStackOverflowIsCaught(t *testing.T) {
+	source := "var f = fun (n) { return f(n + 1); };\n" +
+		"try { f(0); } catch (e) { print e.kind + \": \" + e.message; }\nprint \"after\";"
+	output, err := interpretTestScript(t, source, nil)
+	want := "RuntimeError: stack overflow, more than 10000 nested calls\nafter\n"
+	if err != nil || output != want {
+		t.Errorf("printed %q and failed with %v, want %q", output, err, want)
+	}
+}
+
+// The standard input is only buffered by the interpreters reading it, the
+// input given by SetInput is read up to its end
+func TestInput(t *testing.T) {
+	interpreter := NewInterpreter()
+	if interpreter.stdin != nil {
+		t.Errorf("the standard input is buffered before being read")
+	}
+	interpreter.SetInput(strings.NewReader("first\r\nsecond"))
+	output := &strings.Builder{}
+	interpreter.SetOutput(output)
+	statements, err := NewGoLox().parse("print input();\nprint readLine();\nprint input();")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = interpreter.interpret(context.Background(), statements, false)
+	if err != nil || output.String() != "first\nsecond\nnil\n" {
+		t.Errorf("printed %q and failed with %v", output.String(), err)
+	}
+}
+
+// The limits and the capabilities of the options stop the script with a typed
+// error at the line exceeding them. DIRECTORY is replaced by the directory of
+// the test in the errors.
+func TestInterpreterLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		options InterpreterOptions
+		source  string
+		output  string
+		// Type and text of the error
+		errType string
+		err     string
+	}{
+		{
+			name:    "steps",
+			options: InterpreterOptions{MaxSteps: 3},
+			source:  "print 1;\nprint 2;\nprint 3;\nprint 4;",
+			output:  "1.000000\n2.000000\n3.000000\n",
+			errType: "*golox.StepLimitError",
+			err:     "[line 4] LIMIT ERROR: more than 3 statements executed",
+		},
+		{
+			name:    "steps in a loop",
+			options: InterpreterOptions{MaxSteps: 10},
+			source:  "var i = 0;\nwhile (true) {\n  i = i + 1;\n}",
+			errType: "*golox.StepLimitError",
+			err:     "[line 3] LIMIT ERROR: more than 10 statements executed",
+		},
+		{
+			name:    "timeout",
+			options: InterpreterOptions{Timeout: 10 * time.Millisecond},
+			source:  "print 1;\nwhile (true) {}",
+			output:  "1.000000\n",
+			errType: "*golox.TimeoutError",
+			err:     "[line 2] LIMIT ERROR: execution longer than 10ms",
+		},
+		{
+			name:    "call depth",
+			options: InterpreterOptions{MaxCallDepth: 5},
+			source:  "var f = fun (n) {\n  return f(n + 1);\n};\nf(0);",
+			errType: "*golox.CallDepthError",
+			err:     "[line 2] LIMIT ERROR: more than 5 nested calls",
+		},
+		{
+			name:    "size of a concatenation",
+			options: InterpreterOptions{MaxSize: 5},
+			source:  "var s = \"abc\";\nprint s + s;",
+			errType: "*golox.SizeLimitError",
+			err:     "[line 2] LIMIT ERROR: size 6 greater than the maximum 5",
+		},
+		{
+			name:    "size of a string created by a native",
+			options: InterpreterOptions{MaxSize: 5},
+			source:  "print 1;\nprint repeat(\"ab\", 3);",
+			output:  "1.000000\n",
+			errType: "*golox.SizeLimitError",
+			err:     "[line 2] LIMIT ERROR: size 6 greater than the maximum 5",
+		},
+		{
+			name:    "size of a list created by a native",
+			options: InterpreterOptions{MaxSize: 5},
+			source:  "print range(5);\nprint range(6);",
+			output:  "[0.000000, 1.000000, 2.000000, 3.000000, 4.000000]\n",
+			errType: "*golox.SizeLimitError",
+			err:     "[line 2] LIMIT ERROR: size 6 greater than the maximum 5",
+		},
+		{
+			name:    "file system natives not registered",
+			source:  "print 1;\nprint readFile(\"inside.txt\");",
+			output:  "1.000000\n",
+			errType: "*golox.RuntimeError",
+			err:     "[line 2] RUNTIME ERROR: Undefined variable 'readFile'",
+		},
+		{
+			name:    "environment natives not registered",
+			source:  "print 1;\nprint getenv(\"HOME\");",
+			output:  "1.000000\n",
+			errType: "*golox.RuntimeError",
+			err:     "[line 2] RUNTIME ERROR: Undefined variable 'getenv'",
+		},
+		{
+			name:    "import without the file system",
+			source:  "print 1;\nimport \"lib.golox\" as lib;",
+			output:  "1.000000\n",
+			errType: "*golox.AccessError",
+			err:     "[line 2] LIMIT ERROR: the import of modules is not allowed",
+		},
+		{
+			name:    "file outside of the root",
+			options: InterpreterOptions{AllowFileSystem: true, FileSystemRoot: "root"},
+			source:  "print readFile(\"inside.txt\");\nprint readFile(\"../secret.txt\");",
+			output:  "inside\n",
+			errType: "*golox.AccessError",
+			err:     "[line 2] LIMIT ERROR: access to 'DIRECTORY/secret.txt' outside of 'DIRECTORY/root' denied",
+		},
+		{
+			name:    "absolute path outside of the root",
+			options: InterpreterOptions{AllowFileSystem: true, FileSystemRoot: "root"},
+			source:  "print 1;\nprint readFile(\"DIRECTORY/secret.txt\");",
+			output:  "1.000000\n",
+			errType: "*golox.AccessError",
+			err:     "[line 2] LIMIT ERROR: access to 'DIRECTORY/secret.txt' outside of 'DIRECTORY/root' denied",
+		},
+		{
+			name:    "symbolic link outside of the root",
+			options: InterpreterOptions{AllowFileSystem: true, FileSystemRoot: "root"},
+			source:  "print 1;\nprint readFile(\"link.txt\");",
+			output:  "1.000000\n",
+			errType: "*golox.AccessError",
+			err:     "[line 2] LIMIT ERROR: access to 'DIRECTORY/root/link.txt' outside of 'DIRECTORY/root' denied",
+		},
+		{
+			name:    "new file outside of the root",
+			options: InterpreterOptions{AllowFileSystem: true, FileSystemRoot: "root"},
+			source:  "print 1;\nwriteFile(\"../new.txt\", \"new\");",
+			output:  "1.000000\n",
+			errType: "*golox.AccessError",
+			err:     "[line 2] LIMIT ERROR: access to 'DIRECTORY/new.txt' outside of 'DIRECTORY/root' denied",
+		},
+	}
+	directory, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(directory, "root"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{"secret.txt": "secret", "root/inside.txt": "inside"} {
+		if err := os.WriteFile(filepath.Join(directory, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Symlink(filepath.Join(directory, "secret.txt"), filepath.Join(directory, "root", "link.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chdirForTest(t, directory)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := strings.ReplaceAll(test.source, "DIRECTORY", directory)
+			statements, err := NewGoLox().parse(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreterWithOptions(test.options)
+			output := &strings.Builder{}
+			interpreter.SetOutput(output)
+			_, err = interpreter.interpret(context.Background(), statements, false)
+			want := strings.ReplaceAll(test.err, "DIRECTORY", directory)
+			if err == nil || fmt.Sprintf("%T", err) != test.errType || err.Error() != want {
+				t.Errorf("%q failed with %T: %v, want %s: %s", test.source, err, err, test.errType, want)
+			}
+			if output.String() != test.output {
+				t.Errorf("%q printed %q, want %q", test.source, output.String(), test.output)
+			}
+		})
+	}
+}
+
+// cancelHook cancels the context of the interpretation before the execution
+// of the statements of a line
+type cancelHook struct {
+	line   int
+	cancel context.CancelCauseFunc
+}
+
+var errTestShutdown = errors.New("shutdown")
+
+func (h *cancelHook) beforeExecute(interp *Interpreter, stmt Stmt[any]) error {
+	if stmtLine(stmt) == h.line {
+		h.cancel(errTestShutdown)
+	}
+	return nil
+}
+
+// A cancelled context stops the script at the next statement of a block, of
+// a loop or of a function, or at the next call, with the line being executed
+func TestInterpreterCancellation(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// Line where the context is cancelled, cancelled before the
+		// interpretation if 0
+		cancelLine int
+		output     string
+		err        string
+	}{
+		{
+			name:   "before the interpretation",
+			source: "print 1;",
+			err:    "[line 0] CANCELLED: shutdown",
+		},
+		{
+			name:       "top-level statement",
+			source:     "print 1;\nprint 2;\nprint 3;",
+			cancelLine: 2,
+			output:     "1.000000\n2.000000\n",
+			err:        "[line 2] CANCELLED: shutdown",
+		},
+		{
+			name:       "block in a loop",
+			source:     "var i = 0;\nwhile (true) {\n  i = i + 1;\n  print i;\n}",
+			cancelLine: 3,
+			err:        "[line 3] CANCELLED: shutdown",
+		},
+		{
+			name:       "loop without block",
+			source:     "var i = 0;\nwhile (true)\n  i = i + 1;\n",
+			cancelLine: 3,
+			err:        "[line 3] CANCELLED: shutdown",
+		},
+		{
+			name:       "function body",
+			source:     "var f = fun () {\n  print 1;\n  print 2;\n};\nf();",
+			cancelLine: 2,
+			output:     "1.000000\n",
+			err:        "[line 2] CANCELLED: shutdown",
+		},
+		{
+			name:       "call",
+			source:     "var f = fun (x) { return x; };\nprint 1;\nprint f(2);",
+			cancelLine: 3,
+			output:     "1.000000\n",
+			err:        "[line 3] CANCELLED: shutdown",
+		},
+		{
+			name:       "callback of a native",
+			source:     "print map(range(3), fun (x) {\n  print x;\n  return x;\n});",
+			cancelLine: 2,
+			output:     "0.000000\n",
+			err:        "[line 2] CANCELLED: shutdown",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreter()
+			output := &strings.Builder{}
+			interpreter.SetOutput(output)
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			if test.cancelLine == 0 {
+				cancel(errTestShutdown)
+			}
+			interpreter.SetExecutionHook(&cancelHook{test.cancelLine, cancel})
+			_, err = interpreter.interpret(ctx, statements, false)
+			var cancellationErr *CancellationError
+			if !errors.As(err, &cancellationErr) || !errors.Is(err, errTestShutdown) || err.Error() != test.err {
+				t.Errorf("%q failed with %v, want %s", test.source, err, test.err)
+			}
+			if output.String() != test.output {
+				t.Errorf("%q printed %q, want %q", test.source, output.String(), test.output)
+			}
+		})
+	}
+}
+
+// A panic of the interpretation becomes an internal error at the line being
+// executed, even in a try statement, and the interpreter can still be used
+func TestPanicIsRecovered(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		line   int
+	}{
+		{
+			name:   "top-level statement",
+			source: "print 1;\npanic();",
+			output: "1.000000\n",
+			line:   2,
+		},
+		{
+			name:   "block in a function",
+			source: "var f = fun () {\n  {\n    panic();\n  }\n};\nf();",
+			line:   3,
+		},
+		{
+			name:   "callback of a native",
+			source: "map(range(1), fun (x) {\n  return panic();\n});",
+			line:   2,
+		},
+		{
+			name:   "try statement",
+			source: "try {\n  panic();\n} catch (e) {\n  print \"caught\";\n}",
+			line:   2,
+		},
+		{
+			name:   "module",
+			source: "import \"lib.golox\" as lib;",
+			line:   2,
+		},
+	}
+	panicking := NewNativeFunction("panic", 0, func(interp *Interpreter, args []any) (any, error) {
+		panic("test panic")
+	})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			files := map[string]string{"script.golox": test.source, "lib.golox": "var a = 1;\npanic();\n"}
+			for name, source := range files {
+				if err := os.WriteFile(filepath.Join(directory, name), []byte(source), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			interpreter := NewInterpreter()
+			interpreter.globals.define("panic", panicking)
+			interpreter.natives.define("panic", panicking)
+			interpreter.setScriptPath(filepath.Join(directory, "script.golox"))
+			output := &strings.Builder{}
+			interpreter.SetOutput(output)
+			run := func(source string) error {
+				statements, err := NewGoLox().parse(source)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = interpreter.interpret(context.Background(), statements, false)
+				return err
+			}
+
+			err := run(test.source)
+			kind, line, message := describeError(err)
+			if kind != "InternalError" || line != test.line || message != "internal error: test panic" {
+				t.Errorf("%q failed with %s at line %d: %q", test.source, kind, line, message)
+			}
+			if output.String() != test.output {
+				t.Errorf("%q printed %q, want %q", test.source, output.String(), test.output)
+			}
+			if interpreter.environment != interpreter.globals || interpreter.module != nil ||
+				interpreter.depth != 0 || interpreter.callDepth != 0 ||
+				len(interpreter.importing) != 1 || len(interpreter.modules) != 1 {
+				t.Errorf("the state of the interpreter is not reset after %q", test.source)
+			}
+			output.Reset()
+			if err := run("var v = \"after\";\nprint v;"); err != nil || output.String() != "after\n" {
+				t.Errorf("the interpreter printed %q and failed with %v after the panic", output.String(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package golox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rules checked by the linter
+const (
+	LintUnusedVariable       = "unused-variable"
+	LintShadowing            = "shadowing"
+	LintUndeclaredAssignment = "undeclared-assignment"
+	LintConstantComparison   = "constant-comparison"
+	LintWrongArity           = "wrong-arity"
+	LintUnreachableCode      = "unreachable-code"
+)
+
+type LintWarning struct {
+	line    int
+	column  int
+	rule    string
+	message string
+}
+
+func NewLintWarning(token *Token, rule string, message string) *LintWarning {
+	return &LintWarning{line: token.line, column: token.column, rule: rule, message: message}
+}
+
+func (w *LintWarning) String() string {
+	return fmt.Sprintf("[line %d] WARNING (%s): %s", w.line, w.rule, w.message)
+}
+
+// Rules disabled by a comment between two lines, all the rules are disabled
+// if no rule is given
+type lintDirective struct {
+	rules     []string
+	firstLine int
+	lastLine  int
+}
+
+// Linter reports the suspicious constructs of a script without executing it.
+//
+// A rule can be disabled in the script by a comment:
+//   - "// lint:disable rule..." disables the rules until the end of the file
+//     or until a comment "// lint:enable rule...",
+//   - "// lint:ignore rule..." disables the rules for the line of the comment
+//     or the next line if the comment is on its own line.
+//
+// Without rule name the directives apply to all the rules.
+type Linter struct {
+	resolver   *Resolver
+	warnings   []*LintWarning
+	directives []*lintDirective
+}
+
+func NewLinter() *Linter {
+	return &Linter{}
+}
+
+func (l *Linter) Lint(source string) ([]*LintWarning, error) {
+	scanner := NewScannerWithComments(source, 100)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		return nil, err
+	}
+	parser := NewParser[any](len(tokens))
+	for _, token := range tokens {
+		if token.tokenType != COMMENT {
+			parser.tokens = append(parser.tokens, token)
+		}
+	}
+	statements, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+	*l = Linter{resolver: NewResolver()}
+	l.readDirectives(tokens)
+	err = l.resolver.Resolve(statements)
+	if err != nil {
+		return nil, err
+	}
+	l.checkDeclarations()
+	l.checkUnreachableCode(statements)
+	for _, statement := range statements {
+		_, err = statement.accept(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(l.warnings, func(i, j int) bool {
+		if l.warnings[i].line != l.warnings[j].line {
+			return l.warnings[i].line < l.warnings[j].line
+		}
+		return l.warnings[i].column < l.warnings[j].column
+	})
+	return l.warnings, nil
+}
+
+func (l *Linter) readDirectives(tokens []*Token) {
+	var previous *Token
+	for _, token := range tokens {
+		if token.tokenType != COMMENT {
+			previous = token
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(token.lexeme, "//"))
+		if len(fields) == 0 {
+			continue
+		}
+		rules := fields[1:]
+		switch fields[0] {
+		case "lint:disable":
+			l.directives = append(l.directives, &lintDirective{rules, token.line, -1})
+		case "lint:enable":
+			for _, directive := range l.directives {
+				if directive.lastLine == -1 && (len(rules) == 0 || equalRules(directive.rules, rules)) {
+					directive.lastLine = token.line
+				}
+			}
+		case "lint:ignore":
+			line := token.line
+			if previous == nil || previous.line != token.line {
+				// The comment is on its own line
+				line += 1
+			}
+			l.directives = append(l.directives, &lintDirective{rules, line, line})
+		}
+	}
+}
+
+func equalRules(rules []string, otherRules []string) bool {
+	return strings.Join(rules, " ") == strings.Join(otherRules, " ")
+}
+
+func (l *Linter) isDisabled(rule string, line int) bool {
+	for _, directive := range l.directives {
+		if line < directive.firstLine || (directive.lastLine != -1 && line > directive.lastLine) {
+			continue
+		}
+		if len(directive.rules) == 0 {
+			return true
+		}
+		for _, disabledRule := range directive.rules {
+			if disabledRule == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (l *Linter) warn(token *Token, rule string, message string) {
+	if !l.isDisabled(rule, token.line) {
+		l.warnings = append(l.warnings, NewLintWarning(token, rule, message))
+	}
+}
+
+// Check the rules using the result of the resolution of the variables
+func (l *Linter) checkDeclarations() {
+	for _, declaration := range l.resolver.declarations {
+		if outer, ok := l.resolver.shadows[declaration]; ok {
+			l.warn(declaration, LintShadowing, fmt.Sprintf(
+				"the variable '%s' shadows the variable declared at line %d", declaration.lexeme, outer.line,
+			))
+		}
+		if l.resolver.depths[declaration] == 0 || l.resolver.parameters[declaration] {
+			// The global variables can be used by the REPL after the script
+			// and the parameters are imposed by the callers
+			continue
+		}
+		isUsed := false
+		for _, reference := range l.resolver.referencesOf(declaration) {
+			if l.resolver.reads[reference] {
+				isUsed = true
+				break
+			}
+		}
+		if !isUsed {
+			l.warn(declaration, LintUnusedVariable, fmt.Sprintf(
+				"the local variable '%s' is never used", declaration.lexeme,
+			))
+		}
+	}
+	for _, name := range l.resolver.unresolved {
+		if !l.resolver.reads[name] {
+			l.warn(name, LintUndeclaredAssignment, fmt.Sprintf(
+				"assignment to the undeclared variable '%s'", name.lexeme,
+			))
+		}
+	}
+}
+
+// Check if statements follow a return or a throw in a list of statements, the
+// warning is given at the first of them
+func (l *Linter) checkUnreachableCode(statements []Stmt[any]) {
+	for i := 0; i < len(statements)-1; i++ {
+		var keyword *Token
+		switch statement := statements[i].(type) {
+		case *Return[any]:
+			keyword = statement.keyword
+		case *Throw[any]:
+			keyword = statement.keyword
+		default:
+			continue
+		}
+		token := keyword
+		if line := stmtLine(statements[i+1]); line != 0 {
+			token = &Token{line: line}
+		}
+		l.warn(token, LintUnreachableCode, fmt.Sprintf(
+			"the code after the %s at line %d is never executed", keyword.lexeme, keyword.line,
+		))
+		return
+	}
+}
+
+func (l *Linter) visitBlockStmt(stmt *Block[any]) (any, error) {
+	l.checkUnreachableCode(stmt.statements)
+	for _, statement := range stmt.statements {
+		_, err := statement.accept(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitExpressionStmt(stmt *Expression[any]) (any, error) {
+	return stmt.expression.accept(l)
+}
+
+func (l *Linter) visitIfStmt(stmt *If[any]) (any, error) {
+	_, err := stmt.condition.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	_, err = stmt.thenBranch.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.elseBranch != nil {
+		return stmt.elseBranch.accept(l)
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitImportStmt(stmt *Import[any]) (any, error) {
+	return nil, nil
+}
+
+func (l *Linter) visitPrintStmt(stmt *Print[any]) (any, error) {
+	return stmt.expression.accept(l)
+}
+
+func (l *Linter) visitReturnStmt(stmt *Return[any]) (any, error) {
+	if stmt.value != nil {
+		return stmt.value.accept(l)
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitThrowStmt(stmt *Throw[any]) (any, error) {
+	return stmt.value.accept(l)
+}
+
+func (l *Linter) visitTryStmt(stmt *Try[any]) (any, error) {
+	for _, clause := range []Stmt[any]{stmt.body, stmt.handler, stmt.finalizer} {
+		if clause == nil {
+			continue
+		}
+		_, err := clause.accept(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitVarStmt(stmt *Var[any]) (any, error) {
+	if stmt.initializer != nil {
+		return stmt.initializer.accept(l)
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitWhileStmt(stmt *While[any]) (any, error) {
+	_, err := stmt.condition.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.body.accept(l)
+}
+
+func (l *Linter) visitAssignExpr(expr *Assign[any]) (any, error) {
+	return expr.value.accept(l)
+}
+
+func (l *Linter) visitBinaryExpr(expr *Binary[any]) (any, error) {
+	if expr.operator.tokenType == EQUAL_EQUAL || expr.operator.tokenType == BANG_EQUAL {
+		left, okLeft := expr.left.(*Literal[any])
+		right, okRight := expr.right.(*Literal[any])
+		if okLeft && okRight && literalType(left.value) != literalType(right.value) {
+			l.warn(expr.operator, LintConstantComparison, fmt.Sprintf(
+				"the comparison of a %s and a %s is always %t",
+				literalType(left.value), literalType(right.value), expr.operator.tokenType == BANG_EQUAL,
+			))
+		}
+	}
+	_, err := expr.left.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	return expr.right.accept(l)
+}
+
+func (l *Linter) visitCallExpr(expr *Call[any]) (any, error) {
+	if variable, ok := expr.callee.(*Variable[any]); ok && l.resolver.isNative(variable.name) {
+		native, ok := l.resolver.natives[variable.name.lexeme].(GoLoxCallable)
+		if ok && !native.arity().accepts(len(expr.arguments)) {
+			l.warn(expr.paren, LintWrongArity, fmt.Sprintf(
+				"'%s' expects %s but got %d",
+				variable.name.lexeme, native.arity(), len(expr.arguments),
+			))
+		}
+	}
+	_, err := expr.callee.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	for _, argument := range expr.arguments {
+		_, err = argument.accept(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (l *Linter) visitFunctionExpr(expr *Function[any]) (any, error) {
+	for _, value := range expr.defaults {
+		if value == nil {
+			continue
+		}
+		_, err := value.accept(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return l.visitBlockStmt(NewBlock(expr.body))
+}
+
+func (l *Linter) visitGetExpr(expr *Get[any]) (any, error) {
+	return expr.object.accept(l)
+}
+
+func (l *Linter) visitGroupingExpr(expr *Grouping[any]) (any, error) {
+	return expr.expression.accept(l)
+}
+
+func (l *Linter) visitLiteralExpr(expr *Literal[any]) (any, error) {
+	return nil, nil
+}
+
+func (l *Linter) visitLogicalExpr(expr *Logical[any]) (any, error) {
+	_, err := expr.left.accept(l)
+	if err != nil {
+		return nil, err
+	}
+	return expr.right.accept(l)
+}
+
+func (l *Linter) visitUnaryExpr(expr *Unary[any]) (any, error) {
+	return expr.right.accept(l)
+}
+
+func (l *Linter) visitVariableExpr(expr *Variable[any]) (any, error) {
+	return nil, nil
+}
+
+// Get the name of the type of a literal value
+func literalType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return "value"
+}
@@ -0,0 +1,117 @@
+package golox
+
+import "testing"
+
+func TestLinter(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		warnings []string
+	}{
+		{
+			name:   "unused local variable",
+			source: "{\n  var unused = 1;\n}",
+			warnings: []string{
+				"[line 2] WARNING (unused-variable): the local variable 'unused' is never used",
+			},
+		},
+		{
+			name:   "unused global variable and parameter",
+			source: "var global = 1;\nvar f = fun (a) { return 1; };",
+		},
+		{
+			name:   "shadowing",
+			source: "var a = 1;\n{\n  var a = 2;\n  print a;\n}",
+			warnings: []string{
+				"[line 3] WARNING (shadowing): the variable 'a' shadows the variable declared at line 1",
+			},
+		},
+		{
+			name:   "undeclared assignment",
+			source: "{\n  undeclared = 1;\n}",
+			warnings: []string{
+				"[line 2] WARNING (undeclared-assignment): assignment to the undeclared variable 'undeclared'",
+			},
+		},
+		{
+			name:   "constant comparison",
+			source: "print 1 == \"1\";\nprint nil != false;\nprint 1 == 2;",
+			warnings: []string{
+				"[line 1] WARNING (constant-comparison): the comparison of a number and a string is always false",
+				"[line 2] WARNING (constant-comparison): the comparison of a nil and a boolean is always true",
+			},
+		},
+		{
+			name:   "wrong arity",
+			source: "print len(\"a\", \"b\");\nprint clock(1);\nprint substr(\"abc\", 1);",
+			warnings: []string{
+				"[line 1] WARNING (wrong-arity): 'len' expects 1 argument but got 2",
+				"[line 2] WARNING (wrong-arity): 'clock' expects 0 arguments but got 1",
+			},
+		},
+		{
+			name:   "unreachable code after a return",
+			source: "var f = fun (x) { return x; print \"dead\"; };",
+			warnings: []string{
+				"[line 1] WARNING (unreachable-code): the code after the return at line 1 is never executed",
+			},
+		},
+		{
+			name:   "unreachable code after a throw",
+			source: "{\n  throw \"error\";\n  print \"dead\";\n  print \"dead too\";\n}",
+			warnings: []string{
+				"[line 3] WARNING (unreachable-code): the code after the throw at line 2 is never executed",
+			},
+		},
+		{
+			name:   "return in a branch",
+			source: "var f = fun (x) {\n  if (x) return 1;\n  return 2;\n};",
+		},
+		{
+			name: "disable and enable a rule",
+			source: "// lint:disable shadowing\nvar a = 1;\n{\n  var a = 2;\n  print a;\n}\n" +
+				"// lint:enable shadowing\n{\n  var a = 3;\n  print a;\n}",
+			warnings: []string{
+				"[line 9] WARNING (shadowing): the variable 'a' shadows the variable declared at line 2",
+			},
+		},
+		{
+			name:   "disable all the rules",
+			source: "// lint:disable\n{\n  var x = 1;\n  y = 1;\n}",
+		},
+		{
+			name:   "ignore the rules on a line",
+			source: "{\n  var x = 1; // lint:ignore unused-variable\n  // lint:ignore\n  var y = 2;\n  var z = 3;\n}",
+			warnings: []string{
+				"[line 5] WARNING (unused-variable): the local variable 'z' is never used",
+			},
+		},
+		{
+			name:   "ignore another rule",
+			source: "{\n  // lint:ignore shadowing\n  var x = 1;\n}",
+			warnings: []string{
+				"[line 3] WARNING (unused-variable): the local variable 'x' is never used",
+			},
+		},
+		{
+			name:   "ignore unreachable code",
+			source: "var f = fun () {\n  return 1;\n  // lint:ignore unreachable-code\n  print \"dead\";\n};",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			warnings, err := NewLinter().Lint(test.source)
+			if err != nil {
+				t.Fatalf("cannot lint %q: %s", test.source, err)
+			}
+			if len(warnings) != len(test.warnings) {
+				t.Fatalf("%q gives the warnings %v, want %v", test.source, warnings, test.warnings)
+			}
+			for i, warning := range warnings {
+				if warning.String() != test.warnings[i] {
+					t.Errorf("%q gives the warning %q, want %q", test.source, warning, test.warnings[i])
+				}
+			}
+		})
+	}
+}
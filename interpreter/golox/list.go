@@ -0,0 +1,10 @@
+package golox
+
+// LoxList is an ordered list of values
+type LoxList struct {
+	elements []any
+}
+
+func NewLoxList(elements []any) *LoxList {
+	return &LoxList{elements: elements}
+}
@@ -0,0 +1,290 @@
+package golox
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Natives working on lists, the lists are not modified: the natives return new
+// lists. The functions given as arguments are called with the elements.
+var listNatives = []*NativeFunction{
+	NewNativeFunction("map", 2, nativeMap),
+	NewNativeFunction("filter", 2, nativeFilter),
+	NewNativeFunctionWithArity("reduce", Arity{min: 2, max: 3}, nativeReduce),
+	NewNativeFunction("forEach", 2, nativeForEach),
+	NewNativeFunctionWithArity("sort", Arity{min: 1, max: 2}, nativeSort),
+	NewNativeFunction("any", 2, nativeAny),
+	NewNativeFunction("all", 2, nativeAll),
+	NewNativeFunctionWithArity("range", Arity{min: 1, max: 3}, nativeRange),
+	NewNativeFunction("zip", 2, nativeZip),
+	NewNativeFunction("enumerate", 1, nativeEnumerate),
+}
+
+// Maximum number of elements of a list created by range, whatever the options
+// of the interpreter
+const maxRangeSize = 1 << 24
+
+// Get the list and the function given as arguments of a native
+func listAndCallableArguments(function string, args []any) (*LoxList, GoLoxCallable, error) {
+	list, err := listArgument(function, "list", args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	callable, err := callableArgument(function, "function", args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, callable, nil
+}
+
+// Get the list of the results of the function applied to each element
+func nativeMap(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("map", args)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, len(list.elements))
+	for i, element := range list.elements {
+		elements[i], err = interp.callCallback("map", callable, []any{element})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewLoxList(elements), nil
+}
+
+// Get the list of the elements for which the function returns a truthy value
+func nativeFilter(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("filter", args)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, 0, len(list.elements))
+	for _, element := range list.elements {
+		keep, err := interp.callCallback("filter", callable, []any{element})
+		if err != nil {
+			return nil, err
+		}
+		if interp.isTruthy(keep) {
+			elements = append(elements, element)
+		}
+	}
+	return NewLoxList(elements), nil
+}
+
+// Combine the elements from the first to the last with a function taking the
+// accumulated value and an element. Without initial value the accumulated
+// value starts with the first element.
+func nativeReduce(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("reduce", args)
+	if err != nil {
+		return nil, err
+	}
+	elements := list.elements
+	var accumulator any
+	if len(args) > 2 {
+		accumulator = args[2]
+	} else if len(elements) == 0 {
+		return nil, fmt.Errorf("reduce: the list is empty and there is no initial value")
+	} else {
+		accumulator = elements[0]
+		elements = elements[1:]
+	}
+	for _, element := range elements {
+		accumulator, err = interp.callCallback("reduce", callable, []any{accumulator, element})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accumulator, nil
+}
+
+// Call the function with each element
+func nativeForEach(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("forEach", args)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range list.elements {
+		_, err = interp.callCallback("forEach", callable, []any{element})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Get the elements in ascending order, the sort is stable. Without comparison
+// function the elements must be all numbers or all strings. The comparison
+// function returns a negative number if its first argument comes first, a
+// positive number if it comes last and 0 if they are equivalent.
+func nativeSort(interp *Interpreter, args []any) (any, error) {
+	list, err := listArgument("sort", "list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	var compare func(a any, b any) (float64, error)
+	if len(args) > 1 {
+		callable, err := callableArgument("sort", "comparison", args[1])
+		if err != nil {
+			return nil, err
+		}
+		compare = func(a any, b any) (float64, error) {
+			order, err := interp.callCallback("sort", callable, []any{a, b})
+			if err != nil {
+				return 0, err
+			}
+			number, ok := order.(float64)
+			if !ok {
+				return 0, fmt.Errorf("sort: the comparison must return a number, got %s", typeName(order))
+			}
+			return number, nil
+		}
+	} else {
+		compare = compareValues
+	}
+	elements := append([]any{}, list.elements...)
+	// The first error stops the comparisons, the order of the elements is
+	// then meaningless
+	var sortErr error
+	sort.SliceStable(elements, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		order, err := compare(elements[i], elements[j])
+		sortErr = err
+		return order < 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	return NewLoxList(elements), nil
+}
+
+// Compare two numbers or two strings for the sort without comparison function
+func compareValues(a any, b any) (float64, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a - b, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			if a < b {
+				return -1, nil
+			} else if a > b {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("sort: cannot compare a %s and a %s without comparison function", typeName(a), typeName(b))
+}
+
+// Check if the function returns a truthy value for at least one element
+func nativeAny(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("any", args)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range list.elements {
+		value, err := interp.callCallback("any", callable, []any{element})
+		if err != nil {
+			return nil, err
+		}
+		if interp.isTruthy(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Check if the function returns a truthy value for all the elements
+func nativeAll(interp *Interpreter, args []any) (any, error) {
+	list, callable, err := listAndCallableArguments("all", args)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range list.elements {
+		value, err := interp.callCallback("all", callable, []any{element})
+		if err != nil {
+			return nil, err
+		}
+		if !interp.isTruthy(value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Get the numbers from start included to end excluded separated by step:
+// range(end) starts at 0 and the step is 1 by default
+func nativeRange(interp *Interpreter, args []any) (any, error) {
+	start, end, step := 0.0, 0.0, 1.0
+	var err error
+	if len(args) == 1 {
+		end, err = numberArgument("range", "end", args[0])
+	} else {
+		start, err = numberArgument("range", "start", args[0])
+		if err == nil {
+			end, err = numberArgument("range", "end", args[1])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 2 {
+		step, err = numberArgument("range", "step", args[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if step == 0 || math.IsNaN(step) {
+		return nil, fmt.Errorf("range: the argument 'step' must not be 0")
+	}
+	count := math.Max(math.Ceil((end-start)/step), 0)
+	if math.IsNaN(count) || count > maxRangeSize {
+		return nil, fmt.Errorf("range: too many numbers between %s and %s", interp.stringify(start), interp.stringify(end))
+	}
+	err = interp.checkSize(0, int(count))
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, int(count))
+	for i := range elements {
+		elements[i] = start + float64(i)*step
+	}
+	return NewLoxList(elements), nil
+}
+
+// Get the list of the pairs of the elements at the same position in two
+// lists, the length is the one of the shortest list
+func nativeZip(interp *Interpreter, args []any) (any, error) {
+	first, err := listArgument("zip", "first", args[0])
+	if err != nil {
+		return nil, err
+	}
+	second, err := listArgument("zip", "second", args[1])
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, min(len(first.elements), len(second.elements)))
+	for i := range elements {
+		elements[i] = NewLoxList([]any{first.elements[i], second.elements[i]})
+	}
+	return NewLoxList(elements), nil
+}
+
+// Get the list of the pairs of the positions and the elements of a list
+func nativeEnumerate(interp *Interpreter, args []any) (any, error) {
+	list, err := listArgument("enumerate", "list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, len(list.elements))
+	for i, element := range list.elements {
+		elements[i] = NewLoxList([]any{float64(i), element})
+	}
+	return NewLoxList(elements), nil
+}
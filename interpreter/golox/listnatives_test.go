@@ -0,0 +1,141 @@
+package golox
+
+import "testing"
+
+// The errors of the callbacks are reported at their line in the callback, the
+// errors of the natives at the line of the call
+func TestListNatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Kind, line and message of the error, no error if the kind is empty
+		kind    string
+		line    int
+		message string
+	}{
+		{
+			name: "map, filter and reduce",
+			source: "print map(range(3), fun (x) { return x * 2; });\nprint filter(range(5), fun (x) { return x % 2 == 0; });\n" +
+				"print reduce(range(4), fun (a, x) { return a + x; });\nprint reduce(range(4), fun (a, x) { return a + x; }, 10);",
+			output: "[0.000000, 2.000000, 4.000000]\n[0.000000, 2.000000, 4.000000]\n6.000000\n16.000000\n",
+		},
+		{
+			name: "forEach, any and all",
+			source: "forEach(range(2), fun (x) { print x; });\nprint any(range(3), fun (x) { return x > 1; });\n" +
+				"print all(range(3), fun (x) { return x > 1; });",
+			output: "0.000000\n1.000000\ntrue\nfalse\n",
+		},
+		{
+			name: "sort",
+			source: "print sort(map(range(3), fun (x) { return 2 - x; }));\nprint sort(split(\"b c a\", \" \"));\n" +
+				"print sort(range(3), fun (a, b) { return b - a; });",
+			output: "[0.000000, 1.000000, 2.000000]\n[a, b, c]\n[2.000000, 1.000000, 0.000000]\n",
+		},
+		{
+			name:   "range, zip and enumerate",
+			source: "print range(2, 5);\nprint range(5, 0, -2);\nprint zip(range(2), range(3));\nprint enumerate(split(\"a b\", \" \"));",
+			output: "[2.000000, 3.000000, 4.000000]\n[5.000000, 3.000000, 1.000000]\n" +
+				"[[0.000000, 0.000000], [1.000000, 1.000000]]\n[[0.000000, a], [1.000000, b]]\n",
+		},
+		{
+			name:    "runtime error in a callback",
+			source:  "var f = fun (x) {\n  return x + \"a\";\n};\nprint map(range(2),\n  f);",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "The operands must be two numbers or two strings",
+		},
+		{
+			name:    "undefined variable in a callback",
+			source:  "print filter(range(2), fun (x) {\n  return missing;\n});",
+			kind:    "RuntimeError",
+			line:    2,
+			message: "Undefined variable 'missing'",
+		},
+		{
+			name:    "throw in a callback",
+			source:  "print reduce(range(2), fun (a, x) {\n  throw \"boom\";\n});",
+			kind:    "ThrowError",
+			line:    2,
+			message: "boom",
+		},
+		{
+			name: "throw in a callback caught by the caller",
+			source: "try {\n  forEach(range(2), fun (x) {\n    throw \"boom\" + str(x);\n  });\n" +
+				"} catch (e) {\n  print e.message + \" at line \" + str(e.line);\n}",
+			output: "boom0.000000 at line 3.000000\n",
+		},
+		{
+			name:    "error in a nested callback",
+			source:  "print map(range(2), fun (x) {\n  return map(range(2), fun (y) {\n    return y / nil;\n  });\n});",
+			kind:    "RuntimeError",
+			line:    3,
+			message: "Right operand must be a number",
+		},
+		{
+			name:    "invalid result of a callback",
+			source:  "print 1;\nprint sort(range(3), fun (a, b) {\n  return nil;\n});",
+			output:  "1.000000\n",
+			kind:    "NativeError",
+			line:    2,
+			message: "sort: the comparison must return a number, got nil",
+		},
+		{
+			name:    "arity of a callback",
+			source:  "print sort(range(2), fun (a) { return 0; });",
+			kind:    "NativeError",
+			line:    1,
+			message: "sort: the function given as argument expects 1 argument but got 2",
+		},
+		{
+			name:    "reduce of an empty list",
+			source:  "print reduce(range(0), fun (a, x) { return a + x; });",
+			kind:    "NativeError",
+			line:    1,
+			message: "reduce: the list is empty and there is no initial value",
+		},
+		{
+			name:    "range with a zero step",
+			source:  "print range(0, 1, 0);",
+			kind:    "NativeError",
+			line:    1,
+			message: "range: the argument 'step' must not be 0",
+		},
+		{
+			name:    "list not a list",
+			source:  "print map(1, fun (x) { return x; });",
+			kind:    "NativeError",
+			line:    1,
+			message: "map: the argument 'list' must be a list, got number",
+		},
+		{
+			name:    "function not a function",
+			source:  "print map(range(1), 1);",
+			kind:    "NativeError",
+			line:    1,
+			message: "map: the argument 'function' must be a function, got number",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := interpretTestScript(t, test.source, nil)
+			if output != test.output {
+				t.Errorf("%q printed %q, want %q", test.source, output, test.output)
+			}
+			if test.kind == "" {
+				if err != nil {
+					t.Errorf("%q failed with %v", test.source, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("%q did not fail", test.source)
+			}
+			kind, line, message := describeError(err)
+			if kind != test.kind || line != test.line || message != test.message {
+				t.Errorf("%q failed with %s at line %d: %q, want %s at line %d: %q",
+					test.source, kind, line, message, test.kind, test.line, test.message)
+			}
+		})
+	}
+}
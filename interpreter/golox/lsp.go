@@ -0,0 +1,494 @@
+package golox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Error codes defined by JSON-RPC
+const (
+	lspParseError     = -32700
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+)
+
+// Kinds of symbols and diagnostics defined by the protocol
+const (
+	lspSymbolKindVariable = 13
+	lspSeverityError      = 1
+)
+
+// Types and modifiers of the semantic tokens, the index in the list is the
+// value sent to the client
+var lspSemanticTokenTypes = []string{"keyword", "variable", "function", "string", "number", "operator", "comment"}
+var lspSemanticTokenModifiers = []string{"declaration"}
+
+// LanguageServer implements the Language Server Protocol for the GoLox
+// scripts. The messages are exchanged with JSON-RPC on the given reader and
+// writer, usually the standard input and output.
+type LanguageServer struct {
+	reader *bufio.Reader
+	writer io.Writer
+	// Documents opened by the client by URI
+	documents  map[string]*lspDocument
+	isShutdown bool
+}
+
+// Analysis of the last version of a document
+type lspDocument struct {
+	lines    []string
+	tokens   []*Token
+	errors   []*SyntaxError
+	resolver *Resolver
+}
+
+type lspMessage struct {
+	JsonRpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	Uri   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument struct {
+		Uri string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+func NewLanguageServer(reader io.Reader, writer io.Writer) *LanguageServer {
+	return &LanguageServer{
+		reader:    bufio.NewReader(reader),
+		writer:    writer,
+		documents: make(map[string]*lspDocument),
+	}
+}
+
+// Handle the messages until the exit notification or the end of the input
+func (ls *LanguageServer) Serve() error {
+	for {
+		content, err := readBaseMessage(ls.reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var message lspMessage
+		if err := json.Unmarshal(content, &message); err != nil {
+			// The id of the request is unknown, JSON-RPC requires a null id
+			id := json.RawMessage("null")
+			err = ls.writeMessage(&lspMessage{Id: &id, Error: &lspError{lspParseError, err.Error()}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if message.Method == "exit" {
+			if !ls.isShutdown {
+				return errors.New("exit notification received before the shutdown request")
+			}
+			return nil
+		}
+		result, respErr := ls.handle(message.Method, message.Params)
+		if message.Id == nil {
+			// Notifications have no response
+			continue
+		}
+		response := &lspMessage{Id: message.Id, Result: result, Error: respErr}
+		if result == nil && respErr == nil {
+			response.Result = json.RawMessage("null")
+		}
+		if err := ls.writeMessage(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (ls *LanguageServer) handle(method string, params json.RawMessage) (any, *lspError) {
+	switch method {
+	case "initialize":
+		return ls.initialize(), nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		ls.isShutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				Uri  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		return nil, ls.update(p.TextDocument.Uri, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument struct {
+				Uri string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// The documents are synchronized in full, the last change is the
+		// whole content of the document
+		return nil, ls.update(p.TextDocument.Uri, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var p lspTextDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		delete(ls.documents, p.TextDocument.Uri)
+		return nil, nil
+	case "textDocument/definition":
+		return ls.withPosition(params, ls.definition)
+	case "textDocument/references":
+		return ls.withPosition(params, ls.references)
+	case "textDocument/hover":
+		return ls.withPosition(params, ls.hover)
+	case "textDocument/documentSymbol":
+		var p lspTextDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		return ls.documentSymbols(p.TextDocument.Uri), nil
+	case "textDocument/semanticTokens/full":
+		var p lspTextDocumentPositionParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &lspError{lspInvalidParams, err.Error()}
+		}
+		return ls.semanticTokens(p.TextDocument.Uri), nil
+	}
+	return nil, &lspError{lspMethodNotFound, fmt.Sprintf("method not found: %s", method)}
+}
+
+func (ls *LanguageServer) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			// Full synchronization of the documents
+			"textDocumentSync":       1,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"documentSymbolProvider": true,
+			"semanticTokensProvider": map[string]any{
+				"legend": map[string]any{
+					"tokenTypes":     lspSemanticTokenTypes,
+					"tokenModifiers": lspSemanticTokenModifiers,
+				},
+				"full": true,
+			},
+		},
+		"serverInfo": map[string]any{"name": "golox"},
+	}
+}
+
+// Analyze the new content of the document and publish its diagnostics
+func (ls *LanguageServer) update(uri string, text string) *lspError {
+	document := &lspDocument{lines: strings.Split(text, "\n")}
+	scanner := NewScannerWithComments(text, 100)
+	tokens, err := scanner.scanTokens()
+	document.tokens = tokens
+	document.errors = append(document.errors, ls.syntaxErrors(err)...)
+	parser := NewParser[any](len(tokens))
+	for _, token := range tokens {
+		if token.tokenType != COMMENT {
+			parser.tokens = append(parser.tokens, token)
+		}
+	}
+	// The statements without syntax error are analyzed so the requests still
+	// work while the document is being edited
+	statements, err := parser.ParseRecovering()
+	document.errors = append(document.errors, ls.syntaxErrors(err)...)
+	document.resolver = NewResolver()
+	document.resolver.Resolve(statements)
+	ls.documents[uri] = document
+	diagnostics := make([]any, 0, len(document.errors))
+	for _, syntaxErr := range document.errors {
+		line := syntaxErr.line - 1
+		if line >= len(document.lines) {
+			line = len(document.lines) - 1
+		}
+		diagnostics = append(diagnostics, map[string]any{
+			"range": lspRange{
+				Start: lspPosition{line, 0},
+				End:   lspPosition{line, document.character(line, len(document.lines[line]))},
+			},
+			"severity": lspSeverityError,
+			"source":   "golox",
+			"message":  syntaxErr.message,
+		})
+	}
+	err = ls.notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+	if err != nil {
+		return &lspError{lspParseError, err.Error()}
+	}
+	return nil
+}
+
+func (ls *LanguageServer) syntaxErrors(err error) []*SyntaxError {
+	var syntaxErrs *SyntaxErrors
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErrs) {
+		return syntaxErrs.errors
+	} else if errors.As(err, &syntaxErr) {
+		return []*SyntaxError{syntaxErr}
+	}
+	return nil
+}
+
+// Decode the position of a request and find the declaration of the variable
+// at this position before calling the handler
+func (ls *LanguageServer) withPosition(
+	params json.RawMessage,
+	handler func(uri string, document *lspDocument, token *Token, declaration *Token) any,
+) (any, *lspError) {
+	var p lspTextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &lspError{lspInvalidParams, err.Error()}
+	}
+	document, ok := ls.documents[p.TextDocument.Uri]
+	if !ok {
+		return nil, nil
+	}
+	token := document.tokenAt(p.Position)
+	if token == nil {
+		return nil, nil
+	}
+	return handler(p.TextDocument.Uri, document, token, document.resolver.declarationOf(token)), nil
+}
+
+func (ls *LanguageServer) definition(uri string, document *lspDocument, token *Token, declaration *Token) any {
+	if declaration == nil {
+		return nil
+	}
+	return lspLocation{uri, document.tokenRange(declaration)}
+}
+
+func (ls *LanguageServer) references(uri string, document *lspDocument, token *Token, declaration *Token) any {
+	if declaration == nil {
+		return nil
+	}
+	tokens := append(document.resolver.referencesOf(declaration), declaration)
+	sortTokens(tokens)
+	locations := make([]lspLocation, 0, len(tokens))
+	for _, reference := range tokens {
+		locations = append(locations, lspLocation{uri, document.tokenRange(reference)})
+	}
+	return locations
+}
+
+func (ls *LanguageServer) hover(uri string, document *lspDocument, token *Token, declaration *Token) any {
+	var contents string
+	if declaration != nil {
+		scope := "global variable"
+		if document.resolver.depths[declaration] > 0 {
+			scope = "local variable"
+		}
+		contents = fmt.Sprintf(
+			"```golox\nvar %s\n```\n%s declared at line %d",
+			declaration.lexeme, scope, declaration.line,
+		)
+	} else if document.resolver.isNative(token) {
+		native := document.resolver.natives[token.lexeme].(GoLoxCallable)
+		contents = fmt.Sprintf(
+			"```golox\n%s\n```\nnative function taking %s",
+			token.lexeme, native.arity(),
+		)
+	} else {
+		return nil
+	}
+	return map[string]any{
+		"contents": map[string]any{"kind": "markdown", "value": contents},
+		"range":    document.tokenRange(token),
+	}
+}
+
+func (ls *LanguageServer) documentSymbols(uri string) any {
+	document, ok := ls.documents[uri]
+	if !ok {
+		return nil
+	}
+	symbols := make([]any, 0, len(document.resolver.declarations))
+	for _, declaration := range document.resolver.declarations {
+		symbols = append(symbols, map[string]any{
+			"name":           declaration.lexeme,
+			"kind":           lspSymbolKindVariable,
+			"range":          document.tokenRange(declaration),
+			"selectionRange": document.tokenRange(declaration),
+		})
+	}
+	return symbols
+}
+
+func (ls *LanguageServer) semanticTokens(uri string) any {
+	document, ok := ls.documents[uri]
+	if !ok {
+		return nil
+	}
+	data := make([]int, 0, 5*len(document.tokens))
+	previousLine, previousColumn := 0, 0
+	for _, token := range document.tokens {
+		tokenType := document.semanticTokenType(token)
+		// The tokens on several lines are not supported by all the clients
+		if tokenType < 0 || strings.Contains(token.lexeme, "\n") {
+			continue
+		}
+		line := token.line - 1
+		column := document.character(line, token.column)
+		if line != previousLine {
+			previousColumn = 0
+		}
+		modifiers := 0
+		if _, ok := document.resolver.depths[token]; ok {
+			modifiers = 1
+		}
+		length := len(utf16.Encode([]rune(token.lexeme)))
+		data = append(data, line-previousLine, column-previousColumn, length, tokenType, modifiers)
+		previousLine, previousColumn = line, column
+	}
+	return map[string]any{"data": data}
+}
+
+// Get the index of the semantic token type of the token, -1 if the token is
+// not highlighted
+func (document *lspDocument) semanticTokenType(token *Token) int {
+	switch token.tokenType {
+	case IDENTIFIER:
+		if document.resolver.isNative(token) {
+			return 2
+		}
+		return 1
+	case STRING:
+		return 3
+	case NUMBER:
+		return 4
+	case COMMENT:
+		return 6
+	case EOF, LEFT_PAREN, RIGHT_PAREN, LEFT_BRACE, RIGHT_BRACE, COMMA, DOT, SEMICOLON:
+		return -1
+	}
+	if _, ok := Keywords[token.lexeme]; ok {
+		return 0
+	}
+	return 5
+}
+
+// Get the identifier at the given position
+func (document *lspDocument) tokenAt(position lspPosition) *Token {
+	column := document.column(position.Line, position.Character)
+	for _, token := range document.tokens {
+		if token.tokenType != IDENTIFIER || token.line-1 != position.Line {
+			continue
+		}
+		if token.column <= column && column <= token.column+len(token.lexeme) {
+			return token
+		}
+	}
+	return nil
+}
+
+func (document *lspDocument) tokenRange(token *Token) lspRange {
+	line := token.startLine() - 1
+	return lspRange{
+		Start: lspPosition{line, document.character(line, token.column)},
+		End:   lspPosition{line, document.character(line, token.column+len(token.lexeme))},
+	}
+}
+
+// Convert a column of a line in bytes, as in the tokens, to a character of the
+// protocol counted in UTF-16 code units
+func (document *lspDocument) character(line int, column int) int {
+	if line < 0 || line >= len(document.lines) {
+		return column
+	}
+	text := document.lines[line]
+	if column > len(text) {
+		// The token continues on the next lines
+		return len(utf16.Encode([]rune(text))) + column - len(text)
+	}
+	return len(utf16.Encode([]rune(text[:column])))
+}
+
+// Convert a character of the protocol counted in UTF-16 code units to a column
+// of a line in bytes
+func (document *lspDocument) column(line int, character int) int {
+	if line < 0 || line >= len(document.lines) {
+		return character
+	}
+	units := 0
+	for column, r := range document.lines[line] {
+		if units >= character {
+			return column
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(document.lines[line])
+}
+
+// Sort the tokens in the order of the source
+func sortTokens(tokens []*Token) {
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].line != tokens[j].line {
+			return tokens[i].line < tokens[j].line
+		}
+		return tokens[i].column < tokens[j].column
+	})
+}
+
+func (ls *LanguageServer) notify(method string, params any) error {
+	content, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return ls.writeMessage(&lspMessage{Method: method, Params: content})
+}
+
+func (ls *LanguageServer) writeMessage(message *lspMessage) error {
+	message.JsonRpc = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return writeBaseMessage(ls.writer, content)
+}
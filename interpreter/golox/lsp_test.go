@@ -0,0 +1,234 @@
+package golox
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+// lspTestClient drives a language server running in a goroutine with
+// JSON-RPC messages
+type lspTestClient struct {
+	*baseProtocolTestTransport
+	nextId int
+	// Parameters of the diagnostics published by the server
+	diagnostics []json.RawMessage
+}
+
+func newLspTestClient(t *testing.T) *lspTestClient {
+	transport := newBaseProtocolTestTransport(t, func(reader io.Reader, writer io.Writer) error {
+		return NewLanguageServer(reader, writer).Serve()
+	})
+	return &lspTestClient{baseProtocolTestTransport: transport}
+}
+
+func (c *lspTestClient) send(message map[string]any) {
+	message["jsonrpc"] = "2.0"
+	c.writeJson(message)
+}
+
+func (c *lspTestClient) notify(method string, params any) {
+	c.send(map[string]any{"method": method, "params": params})
+}
+
+// Send a request and wait for its response, the notifications received in
+// the meantime are recorded
+func (c *lspTestClient) request(method string, params any) *lspMessage {
+	c.nextId += 1
+	c.send(map[string]any{"id": c.nextId, "method": method, "params": params})
+	for {
+		var message lspMessage
+		c.readJson(&message)
+		if message.Method == "textDocument/publishDiagnostics" {
+			c.diagnostics = append(c.diagnostics, message.Params)
+			continue
+		}
+		if message.Id == nil || string(*message.Id) != string(mustMarshal(c.t, c.nextId)) {
+			c.t.Fatalf("unexpected message: %+v", message)
+		}
+		if message.Error != nil {
+			c.t.Fatalf("%s failed: %s", method, message.Error.Message)
+		}
+		return &message
+	}
+}
+
+// Decode the result of a response in the given value
+func (c *lspTestClient) result(message *lspMessage, value any) {
+	content, err := json.Marshal(message.Result)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, value); err != nil {
+		c.t.Fatalf("invalid result %s: %s", content, err)
+	}
+}
+
+func (c *lspTestClient) open(uri string, text string) {
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "golox", "version": 1, "text": text},
+	})
+}
+
+func positionParams(uri string, line int, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func (c *lspTestClient) shutdown() {
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+	c.wait()
+}
+
+const lspTestUri = "file:///test.golox"
+
+const lspTestSource = `var count = 0;
+{
+  var step = 2;
+  count = count + step;
+}
+print len("abc") + count;
+`
+
+func TestLanguageServerSession(t *testing.T) {
+	client := newLspTestClient(t)
+	var initialize struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	client.result(client.request("initialize", map[string]any{"capabilities": map[string]any{}}), &initialize)
+	if initialize.Capabilities["definitionProvider"] != true {
+		t.Errorf("the definitions are not supported: %v", initialize.Capabilities)
+	}
+	client.notify("initialized", map[string]any{})
+	client.open(lspTestUri, lspTestSource)
+
+	// The use of count at line 4 is defined at line 1
+	var definition lspLocation
+	client.result(client.request("textDocument/definition", positionParams(lspTestUri, 3, 10)), &definition)
+	want := lspRange{Start: lspPosition{0, 4}, End: lspPosition{0, 9}}
+	if definition.Uri != lspTestUri || definition.Range != want {
+		t.Errorf("definition = %+v, want %+v", definition, want)
+	}
+
+	var references []lspLocation
+	client.result(client.request("textDocument/references", positionParams(lspTestUri, 0, 5)), &references)
+	lines := []int{}
+	for _, reference := range references {
+		lines = append(lines, reference.Range.Start.Line)
+	}
+	if len(lines) != 4 || lines[0] != 0 || lines[1] != 3 || lines[2] != 3 || lines[3] != 5 {
+		t.Errorf("the references of count are at the lines %v", lines)
+	}
+
+	var hover struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	client.result(client.request("textDocument/hover", positionParams(lspTestUri, 3, 18)), &hover)
+	if hover.Contents.Value != "```golox\nvar step\n```\nlocal variable declared at line 3" {
+		t.Errorf("hover of step = %q", hover.Contents.Value)
+	}
+	client.result(client.request("textDocument/hover", positionParams(lspTestUri, 5, 7)), &hover)
+	if hover.Contents.Value != "```golox\nlen\n```\nnative function taking 1 argument" {
+		t.Errorf("hover of len = %q", hover.Contents.Value)
+	}
+
+	var symbols []struct {
+		Name string `json:"name"`
+	}
+	client.result(client.request("textDocument/documentSymbol", positionParams(lspTestUri, 0, 0)), &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "count" || symbols[1].Name != "step" {
+		t.Errorf("symbols = %+v", symbols)
+	}
+
+	var semanticTokens struct {
+		Data []int `json:"data"`
+	}
+	client.result(client.request("textDocument/semanticTokens/full", positionParams(lspTestUri, 0, 0)), &semanticTokens)
+	// "var" is a keyword and "count" a declared variable
+	first := []int{0, 0, 3, 0, 0, 0, 4, 5, 1, 1}
+	if len(semanticTokens.Data) < len(first) {
+		t.Fatalf("semantic tokens = %v", semanticTokens.Data)
+	}
+	for i, value := range first {
+		if semanticTokens.Data[i] != value {
+			t.Errorf("semantic tokens start with %v, want %v", semanticTokens.Data[:len(first)], first)
+			break
+		}
+	}
+
+	if len(client.diagnostics) != 1 || string(client.diagnostics[0]) !=
+		`{"diagnostics":[],"uri":"`+lspTestUri+`"}` {
+		t.Errorf("diagnostics = %s", client.diagnostics)
+	}
+	client.shutdown()
+}
+
+func TestLanguageServerWithSyntaxError(t *testing.T) {
+	client := newLspTestClient(t)
+	client.request("initialize", map[string]any{"capabilities": map[string]any{}})
+	client.open(lspTestUri, "var total = 1;\nprint total +;\nprint total;\n")
+	var definition lspLocation
+	// The statements around the syntax error are still analyzed
+	client.result(client.request("textDocument/definition", positionParams(lspTestUri, 2, 7)), &definition)
+	if definition.Range.Start != (lspPosition{0, 4}) {
+		t.Errorf("definition = %+v", definition)
+	}
+	var diagnostics struct {
+		Diagnostics []struct {
+			Range   lspRange `json:"range"`
+			Message string   `json:"message"`
+		} `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(client.diagnostics[0], &diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics.Diagnostics) != 1 || diagnostics.Diagnostics[0].Range.Start.Line != 1 {
+		t.Errorf("diagnostics = %+v", diagnostics)
+	}
+	client.shutdown()
+}
+
+func TestLanguageServerUtf16Positions(t *testing.T) {
+	client := newLspTestClient(t)
+	client.request("initialize", map[string]any{"capabilities": map[string]any{}})
+	// "é" is 2 bytes but 1 UTF-16 code unit, "😀" is 4 bytes and 2 code units
+	client.open(lspTestUri, "var s = \"é😀\"; var after = s;\n")
+	var definition lspLocation
+	client.result(client.request("textDocument/definition", positionParams(lspTestUri, 0, 27)), &definition)
+	want := lspRange{Start: lspPosition{0, 4}, End: lspPosition{0, 5}}
+	if definition.Range != want {
+		t.Errorf("definition = %+v, want %+v", definition, want)
+	}
+	var symbols []struct {
+		Name  string   `json:"name"`
+		Range lspRange `json:"range"`
+	}
+	client.result(client.request("textDocument/documentSymbol", positionParams(lspTestUri, 0, 0)), &symbols)
+	want = lspRange{Start: lspPosition{0, 19}, End: lspPosition{0, 24}}
+	if len(symbols) != 2 || symbols[1].Range != want {
+		t.Errorf("symbols = %+v, want the range %+v for after", symbols, want)
+	}
+	client.shutdown()
+}
+
+func TestLanguageServerParseError(t *testing.T) {
+	client := newLspTestClient(t)
+	client.write([]byte("{"))
+	content := client.read()
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(content, &message); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := message["id"]; !ok || string(id) != "null" {
+		t.Errorf("the id of the response to an invalid message must be null: %s", content)
+	}
+	if _, ok := message["error"]; !ok {
+		t.Errorf("the response to an invalid message must be an error: %s", content)
+	}
+	client.shutdown()
+}
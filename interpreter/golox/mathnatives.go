@@ -0,0 +1,145 @@
+package golox
+
+import (
+	"errors"
+	"math"
+)
+
+// Natives working on numbers
+var mathNatives = []*NativeFunction{
+	mathFunction("floor", math.Floor),
+	mathFunction("ceil", math.Ceil),
+	mathFunction("round", math.Round),
+	mathFunction("abs", math.Abs),
+	mathFunction("sqrt", math.Sqrt),
+	mathFunction("sin", math.Sin),
+	mathFunction("cos", math.Cos),
+	mathFunction("tan", math.Tan),
+	mathFunction("log", math.Log),
+	mathFunction("exp", math.Exp),
+	mathFunction2("pow", math.Pow),
+	NewNativeFunctionWithArity("min", Arity{min: 1, max: variadic}, nativeMin),
+	NewNativeFunctionWithArity("max", Arity{min: 1, max: variadic}, nativeMax),
+	NewNativeFunction("div", 2, nativeDiv),
+	NewNativeFunction("mod", 2, nativeMod),
+	NewNativeFunction("random", 0, nativeRandom),
+	NewNativeFunction("seed", 1, nativeSeed),
+}
+
+func defineMathConstants(environment *Environment) {
+	environment.define("PI", math.Pi)
+	environment.define("E", math.E)
+	environment.define("INF", math.Inf(1))
+	environment.define("NAN", math.NaN())
+}
+
+// Create a native applying a function to a number
+func mathFunction(name string, function func(float64) float64) *NativeFunction {
+	return NewNativeFunction(name, 1, func(interp *Interpreter, args []any) (any, error) {
+		x, err := numberArgument(name, "x", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return function(x), nil
+	})
+}
+
+// Create a native applying a function to two numbers
+func mathFunction2(name string, function func(float64, float64) float64) *NativeFunction {
+	return NewNativeFunction(name, 2, func(interp *Interpreter, args []any) (any, error) {
+		x, err := numberArgument(name, "x", args[0])
+		if err != nil {
+			return nil, err
+		}
+		y, err := numberArgument(name, "y", args[1])
+		if err != nil {
+			return nil, err
+		}
+		return function(x, y), nil
+	})
+}
+
+// Get the smallest of the numbers given as arguments
+func nativeMin(interp *Interpreter, args []any) (any, error) {
+	return reduceNumbers("min", args, math.Min)
+}
+
+// Get the largest of the numbers given as arguments
+func nativeMax(interp *Interpreter, args []any) (any, error) {
+	return reduceNumbers("max", args, math.Max)
+}
+
+// Combine the numbers given as arguments from the first to the last
+func reduceNumbers(name string, args []any, function func(float64, float64) float64) (any, error) {
+	result, err := numberArgument(name, "x", args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		y, err := numberArgument(name, "y", arg)
+		if err != nil {
+			return nil, err
+		}
+		result = function(result, y)
+	}
+	return result, nil
+}
+
+// Get the operands of the integer division or of the modulo
+func divisionArguments(name string, args []any) (float64, float64, error) {
+	x, err := numberArgument(name, "x", args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := numberArgument(name, "y", args[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if y == 0 {
+		return 0, 0, errors.New(name + ": division by zero")
+	}
+	return x, y, nil
+}
+
+// Divide and round the quotient down
+func nativeDiv(interp *Interpreter, args []any) (any, error) {
+	x, y, err := divisionArguments("div", args)
+	if err != nil {
+		return nil, err
+	}
+	return math.Floor(x / y), nil
+}
+
+func nativeMod(interp *Interpreter, args []any) (any, error) {
+	x, y, err := divisionArguments("mod", args)
+	if err != nil {
+		return nil, err
+	}
+	return floorMod(x, y), nil
+}
+
+// Get the remainder of the division rounded down: the result has the sign of
+// the divisor, e.g. -1 % 3 is 2, so div(x, y) * y + mod(x, y) is x
+func floorMod(x float64, y float64) float64 {
+	remainder := math.Mod(x, y)
+	if remainder != 0 && (remainder < 0) != (y < 0) {
+		remainder += y
+	}
+	return remainder
+}
+
+// Get a random number in [0, 1)
+func nativeRandom(interp *Interpreter, args []any) (any, error) {
+	return interp.random.Float64(), nil
+}
+
+// Seed the random generator so the following random numbers are always the
+// same
+func nativeSeed(interp *Interpreter, args []any) (any, error) {
+	seed, err := integerArgument("seed", "seed", args[0])
+	if err != nil {
+		return nil, err
+	}
+	interp.random.Seed(int64(seed))
+	return nil, nil
+}
@@ -0,0 +1,101 @@
+package golox
+
+import "testing"
+
+func TestMathNatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Error of the script, no error if empty
+		err string
+	}{
+		{
+			name:   "rounding",
+			source: "print floor(-1.5);\nprint ceil(-1.5);\nprint round(2.5);\nprint round(-2.5);\nprint abs(-3);",
+			output: "-2.000000\n-1.000000\n3.000000\n-3.000000\n3.000000\n",
+		},
+		{
+			name:   "functions",
+			source: "print sqrt(16);\nprint pow(2, 10);\nprint exp(0);\nprint log(E);\nprint sin(0);\nprint cos(PI);\nprint tan(0);",
+			output: "4.000000\n1024.000000\n1.000000\n1.000000\n0.000000\n-1.000000\n0.000000\n",
+		},
+		{
+			name:   "minimum and maximum",
+			source: "print min(3, 1, 2);\nprint max(3, 1, 2);\nprint min(5);",
+			output: "1.000000\n3.000000\n5.000000\n",
+		},
+		{
+			name:   "integer division and modulo",
+			source: "print div(7, 2);\nprint div(-7, 2);\nprint mod(-1, 3);\nprint mod(7, -3);",
+			output: "3.000000\n-4.000000\n2.000000\n-2.000000\n",
+		},
+		{
+			name:   "modulo operator",
+			source: "print 7 % 3;\nprint -7 % 3;\nprint 5.5 % 2;\nprint 1 + 7 % 3 * 2;",
+			output: "1.000000\n2.000000\n1.500000\n3.000000\n",
+		},
+		{
+			name:   "constants",
+			source: "print INF;\nprint -INF;\nprint NAN;\nprint sqrt(-1);\nprint 1 / 0;",
+			output: "+Inf\n-Inf\nNaN\nNaN\n+Inf\n",
+		},
+		{
+			name:   "fixed seed",
+			source: "seed(42);\nprint random();",
+			output: "0.373028\n",
+		},
+		{
+			name: "same seed",
+			source: "seed(42);\nvar a = random();\nvar b = random();\nseed(42);\n" +
+				"print a == random() and b == random();\nprint a != b;\nprint a >= 0 and a < 1;",
+			output: "true\ntrue\ntrue\n",
+		},
+		{
+			name:   "integer division by zero",
+			source: "print 1;\nprint div(1, 0);",
+			output: "1.000000\n",
+			err:    "[line 2] RUNTIME ERROR: div: division by zero",
+		},
+		{
+			name:   "modulo by zero",
+			source: "print mod(1, 0);",
+			err:    "[line 1] RUNTIME ERROR: mod: division by zero",
+		},
+		{
+			name:   "modulo operator by zero",
+			source: "print 1 % 0;",
+			err:    "[line 1] RUNTIME ERROR: Modulo by zero",
+		},
+		{
+			name:   "modulo operator of a string",
+			source: "print 1 % \"a\";",
+			err:    "[line 1] RUNTIME ERROR: Right operand must be a number",
+		},
+		{
+			name:   "argument of a function",
+			source: "print floor(\"a\");",
+			err:    "[line 1] RUNTIME ERROR: floor: the argument 'x' must be a number, got string",
+		},
+		{
+			name:   "second argument of a function",
+			source: "print pow(2, nil);",
+			err:    "[line 1] RUNTIME ERROR: pow: the argument 'y' must be a number, got nil",
+		},
+		{
+			name:   "variadic argument",
+			source: "print max(1, \"2\");",
+			err:    "[line 1] RUNTIME ERROR: max: the argument 'y' must be a number, got string",
+		},
+		{
+			name:   "seed not integer",
+			source: "seed(1.5);",
+			err:    "[line 1] RUNTIME ERROR: seed: the argument 'seed' must be an integer, got number",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkTestScript(t, test.source, test.output, test.err)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoxModule is the value of a module imported by a script, its variables are
+// the variables declared at the top level of the module
+type LoxModule struct {
+	path        string
+	environment *Environment
+}
+
+func (m *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", m.path)
+}
+
+// Get a variable exported by the module, the names starting with an
+// underscore are private to the module
+func (m *LoxModule) get(name *Token) (any, error) {
+	value, ok := m.environment.values[name.lexeme]
+	if !ok || strings.HasPrefix(name.lexeme, "_") {
+		return nil, NewRuntimeError(
+			name,
+			fmt.Sprintf("the module '%s' does not export '%s'", m.path, name.lexeme),
+		)
+	}
+	return value, nil
+}
+
+// Load the module at the path given by the token, the path is relative to the
+// directory of the importing script. A module is executed once, the following
+// imports get the same module.
+func (interp *Interpreter) importModule(keyword *Token, pathToken *Token) (*LoxModule, error) {
+	if !interp.options.AllowFileSystem {
+		return nil, NewAccessError(keyword.line, "the import of modules is not allowed")
+	}
+	path := pathToken.literal.(string)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(interp.directory, path)
+	}
+	path, err := interp.canonicalPath(path)
+	if limitErr, ok := err.(limitError); ok {
+		limitErr.setLine(keyword.line)
+		return nil, err
+	} else if err != nil {
+		return nil, NewRuntimeError(pathToken, fmt.Sprintf("cannot import the module: %s", err))
+	}
+	if module, ok := interp.modules[path]; ok {
+		if module == nil {
+			cycle := append(interp.importing, path)
+			return nil, NewRuntimeError(
+				pathToken,
+				fmt.Sprintf("import cycle: %s", strings.Join(cycle, " -> ")),
+			)
+		}
+		return module, nil
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewRuntimeError(pathToken, fmt.Sprintf("cannot import the module: %s", err))
+	}
+	statements, err := parseModule(string(bytes))
+	if err != nil {
+		return nil, NewModuleError(path, err)
+	}
+	// The module is being loaded until it is registered
+	interp.modules[path] = nil
+	interp.importing = append(interp.importing, path)
+	module := &LoxModule{path: path, environment: NewEnvironmentWithEnclosing(interp.natives)}
+	err = interp.executeModule(module, statements)
+	interp.importing = interp.importing[:len(interp.importing)-1]
+	var moduleErr *ModuleError
+	var runtimeErr *RuntimeError
+	if err != nil {
+		delete(interp.modules, path)
+	}
+	if errors.As(err, &runtimeErr) && !errors.As(err, &moduleErr) {
+		// The error is reported with the innermost module where it happened
+		return nil, NewModuleError(path, err)
+	} else if err != nil {
+		return nil, err
+	}
+	interp.modules[path] = module
+	return module, nil
+}
+
+// Set the path of the main script: its directory is the base of the paths of
+// the imports and the script is part of the import cycles
+func (interp *Interpreter) setScriptPath(path string) {
+	interp.directory = filepath.Dir(path)
+	path, err := interp.canonicalPath(path)
+	if err == nil {
+		interp.modules[path] = nil
+		interp.importing = []string{path}
+	}
+}
+
+// Execute the statements of the module in its own global environment
+func (interp *Interpreter) executeModule(module *LoxModule, statements []Stmt[any]) error {
+	environment, globals, directory, current := interp.environment, interp.globals, interp.directory, interp.module
+	interp.environment, interp.globals = module.environment, module.environment
+	interp.directory = filepath.Dir(module.path)
+	interp.module = module
+	defer func() {
+		interp.environment, interp.globals, interp.directory, interp.module = environment, globals, directory, current
+	}()
+	for _, stmt := range statements {
+		err := interp.checkContext()
+		if err != nil {
+			return err
+		}
+		_, err = interp.execute(stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the absolute path of a module without symbolic links so a module has a
+// single path, the path must be in the root of the file system natives
+func (interp *Interpreter) canonicalPath(path string) (string, error) {
+	// The relative paths are relative to the working directory, not to the
+	// root of the file system natives
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	path, err = allowedPath(interp, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+func parseModule(source string) ([]Stmt[any], error) {
+	scanner := NewScanner(source, 100)
+	tokens, err := scanner.scanTokens()
+	if err != nil {
+		return nil, err
+	}
+	parser := NewParser[any](len(tokens))
+	parser.tokens = append(parser.tokens, tokens...)
+	return parser.Parse()
+}
@@ -0,0 +1,238 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Change the working directory until the end of the test
+func chdirForTest(t *testing.T, directory string) {
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(previous); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// The imports of a script given by a relative path are relative to its
+// directory, inside the root given as a relative path too
+func TestImportWithFileSystemRoot(t *testing.T) {
+	directory := t.TempDir()
+	scripts := filepath.Join(directory, "root", "scripts")
+	if err := os.MkdirAll(scripts, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(scripts, "lib.golox"):       "var two = 2;\n",
+		filepath.Join(scripts, "main.golox"):      "import \"lib.golox\" as lib;\nprint lib.two;\n",
+		filepath.Join(scripts, "escape.golox"):    "import \"../../outside.golox\" as outside;\n",
+		filepath.Join(directory, "outside.golox"): "var three = 3;\n",
+	}
+	for path, source := range files {
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdirForTest(t, directory)
+	run := func(script string) (string, error) {
+		bytes, err := os.ReadFile(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statements, err := NewGoLox().parse(string(bytes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		interpreter := NewInterpreterWithOptions(InterpreterOptions{AllowFileSystem: true, FileSystemRoot: "root"})
+		interpreter.setScriptPath(script)
+		output := &strings.Builder{}
+		interpreter.SetOutput(output)
+		_, err = interpreter.interpret(context.Background(), statements, false)
+		return output.String(), err
+	}
+
+	output, err := run(filepath.Join("root", "scripts", "main.golox"))
+	if err != nil || output != "2.000000\n" {
+		t.Errorf("main.golox printed %q and failed with %v", output, err)
+	}
+	_, err = run(filepath.Join("root", "scripts", "escape.golox"))
+	var accessErr *AccessError
+	if !errors.As(err, &accessErr) || accessErr.line != 1 {
+		t.Errorf("the import outside of the root failed with %v", err)
+	}
+}
+
+// lineRecorder records the lines of the statements executed and of the calls
+type lineRecorder struct {
+	lines []int
+	calls []int
+}
+
+func (r *lineRecorder) beforeExecute(interp *Interpreter, stmt Stmt[any]) error {
+	r.lines = append(r.lines, stmtLine(stmt))
+	return nil
+}
+
+func (r *lineRecorder) beforeCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable) error {
+	r.calls = append(r.calls, expr.paren.line)
+	return nil
+}
+
+func (r *lineRecorder) afterCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable) {}
+
+// The hooks are not notified of the code of the modules, even when the main
+// script calls a function of a module
+func TestModuleCodeIsNotHooked(t *testing.T) {
+	directory := t.TempDir()
+	module := "var _double = fun (x) {\n  return x * 2;\n};\nvar quadruple = fun (x) {\n  return _double(_double(x));\n};\n"
+	if err := os.WriteFile(filepath.Join(directory, "lib.golox"), []byte(module), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source := "import \"lib.golox\" as lib;\nvar triple = fun (x) {\n  return x * 3;\n};\nprint lib.quadruple(triple(1));\n"
+	statements, err := NewGoLox().parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interpreter := NewInterpreter()
+	interpreter.setScriptPath(filepath.Join(directory, "main.golox"))
+	output := &strings.Builder{}
+	interpreter.SetOutput(output)
+	recorder := &lineRecorder{}
+	interpreter.SetExecutionHook(recorder)
+	_, err = interpreter.interpret(context.Background(), statements, false)
+	if err != nil || output.String() != "12.000000\n" {
+		t.Fatalf("printed %q and failed with %v", output.String(), err)
+	}
+	if fmt.Sprint(recorder.lines) != "[1 2 5 3]" || fmt.Sprint(recorder.calls) != "[5 5]" {
+		t.Errorf("the hook is notified of the lines %v and the calls at the lines %v", recorder.lines, recorder.calls)
+	}
+}
+
+// A module is executed once whatever the path used to import it, the imports
+// in a cycle fail. DIRECTORY is replaced by the directory of the script in
+// the output and the errors.
+func TestModules(t *testing.T) {
+	counter := "print \"loading\";\nvar n = 0;\nvar increment = fun () {\n  n = n + 1;\n  return n;\n};\n"
+	tests := []struct {
+		name    string
+		source  string
+		modules map[string]string
+		output  string
+		err     string
+	}{
+		{
+			name: "module imported several times",
+			source: "import \"counter.golox\" as a;\nimport \"counter.golox\" as b;\nfrom \"counter.golox\" import increment;\n" +
+				"a.increment();\nincrement();\nprint b.increment();",
+			modules: map[string]string{"counter.golox": counter},
+			output:  "loading\n3.000000\n",
+		},
+		{
+			name:   "module imported by several modules with different paths",
+			source: "import \"b.golox\" as b;\nimport \"c.golox\" as c;\nprint b.get() + c.get();",
+			modules: map[string]string{
+				"counter.golox":   counter,
+				"b.golox":         "import \"counter.golox\" as counter;\nvar get = fun () { return counter.increment(); };",
+				"c.golox":         "import \"sub/../counter.golox\" as counter;\nvar get = fun () { return counter.increment(); };",
+				"sub/empty.golox": "",
+			},
+			output: "loading\n3.000000\n",
+		},
+		{
+			name:    "natives in a module",
+			source:  "import \"lib.golox\" as lib;\nprint lib.f();\nprint lib;",
+			modules: map[string]string{"lib.golox": "var f = fun () { return len(\"ab\") == 2 and PI > 3; };"},
+			output:  "true\n<module DIRECTORY/lib.golox>\n",
+		},
+		{
+			name:    "import cycle",
+			source:  "print 1;\nimport \"a.golox\" as a;",
+			modules: map[string]string{"a.golox": "import \"b.golox\" as b;", "b.golox": "var x = 1;\nimport \"a.golox\" as a;"},
+			output:  "1.000000\n",
+			err: "[line 2] RUNTIME ERROR: import cycle: DIRECTORY/script.golox -> DIRECTORY/a.golox -> " +
+				"DIRECTORY/b.golox -> DIRECTORY/a.golox (in the module 'DIRECTORY/b.golox')",
+		},
+		{
+			name:   "import of the main script",
+			source: "print 1;\nimport \"script.golox\" as self;",
+			output: "1.000000\n",
+			err:    "[line 2] RUNTIME ERROR: import cycle: DIRECTORY/script.golox -> DIRECTORY/script.golox",
+		},
+		{
+			name:    "import of a private name",
+			source:  "from \"lib.golox\" import _private;",
+			modules: map[string]string{"lib.golox": "var _private = 1;"},
+			err:     "[line 1] RUNTIME ERROR: the module 'DIRECTORY/lib.golox' does not export '_private'",
+		},
+		{
+			name:    "property of a private name",
+			source:  "import \"lib.golox\" as lib;\nprint lib._private;",
+			modules: map[string]string{"lib.golox": "var _private = 1;"},
+			err:     "[line 2] RUNTIME ERROR: the module 'DIRECTORY/lib.golox' does not export '_private'",
+		},
+		{
+			name:   "missing module",
+			source: "import \"missing.golox\" as lib;",
+			err:    "[line 1] RUNTIME ERROR: cannot import the module: lstat DIRECTORY/missing.golox: no such file or directory",
+		},
+		{
+			name:    "syntax error in a module",
+			source:  "import \"lib.golox\" as lib;",
+			modules: map[string]string{"lib.golox": "var a = ;"},
+			err:     "[line 1] SYNTAX ERROR: \"= \\\"=\\\"\" is not a valid expression (in the module 'DIRECTORY/lib.golox')",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The paths of the modules have no symbolic links
+			directory, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{"script.golox": test.source}
+			for name, source := range test.modules {
+				files[name] = source
+			}
+			for name, source := range files {
+				path := filepath.Join(directory, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			statements, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreter()
+			interpreter.setScriptPath(filepath.Join(directory, "script.golox"))
+			output := &strings.Builder{}
+			interpreter.SetOutput(output)
+			_, err = interpreter.interpret(context.Background(), statements, false)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			wantOutput := strings.ReplaceAll(test.output, "DIRECTORY", directory)
+			wantErr := strings.ReplaceAll(test.err, "DIRECTORY", directory)
+			if output.String() != wantOutput || message != wantErr {
+				t.Errorf("%q printed %q and failed with %q, want %q and %q",
+					test.source, output.String(), message, wantOutput, wantErr)
+			}
+		})
+	}
+}
@@ -1,14 +1,132 @@
 package golox
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+// NativeFunction is a native implemented by a Go function. The number of
+// arguments is checked by the interpreter, their types by the function.
+type NativeFunction struct {
+	name       string
+	parameters Arity
+	function   func(interp *Interpreter, args []any) (any, error)
+}
+
+// Create a native taking exactly the given number of arguments
+func NewNativeFunction(
+	name string, parameters int, function func(interp *Interpreter, args []any) (any, error),
+) *NativeFunction {
+	return NewNativeFunctionWithArity(name, exactArity(parameters), function)
+}
+
+// Create a native with optional arguments, the function gets only the
+// arguments given by the call
+func NewNativeFunctionWithArity(
+	name string, parameters Arity, function func(interp *Interpreter, args []any) (any, error),
+) *NativeFunction {
+	return &NativeFunction{name: name, parameters: parameters, function: function}
+}
+
+func (n *NativeFunction) arity() Arity { return n.parameters }
+
+func (n *NativeFunction) call(interp *Interpreter, args []any) (any, error) {
+	return n.function(interp, args)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native function>"
+}
+
+func defineNativeFunctions(environment *Environment, natives ...*NativeFunction) {
+	for _, native := range natives {
+		environment.define(native.name, native)
+	}
+}
+
+// Get the name of the type of a value as seen by the scripts
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxList:
+		return "list"
+	case *LoxModule:
+		return "module"
+	case *LoxError:
+		return "error"
+	case GoLoxCallable:
+		return "function"
+	}
+	return "value"
+}
+
+func argumentError(function string, name string, expected string, value any) error {
+	return fmt.Errorf("%s: the argument '%s' must be %s, got %s", function, name, expected, typeName(value))
+}
+
+func stringArgument(function string, name string, value any) (string, error) {
+	str, ok := value.(string)
+	if !ok {
+		return "", argumentError(function, name, "a string", value)
+	}
+	return str, nil
+}
+
+func numberArgument(function string, name string, value any) (float64, error) {
+	number, ok := value.(float64)
+	if !ok {
+		return 0, argumentError(function, name, "a number", value)
+	}
+	return number, nil
+}
+
+// Largest integer such that all the integers up to it are numbers
+const maxExactInteger = 1 << 53
+
+func integerArgument(function string, name string, value any) (int, error) {
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) || math.IsInf(number, 0) {
+		return 0, argumentError(function, name, "an integer", value)
+	}
+	// The larger numbers could overflow the int
+	if math.Abs(number) > maxExactInteger {
+		return 0, fmt.Errorf("%s: the argument '%s' is out of range", function, name)
+	}
+	return int(number), nil
+}
+
+func listArgument(function string, name string, value any) (*LoxList, error) {
+	list, ok := value.(*LoxList)
+	if !ok {
+		return nil, argumentError(function, name, "a list", value)
+	}
+	return list, nil
+}
+
+func callableArgument(function string, name string, value any) (GoLoxCallable, error) {
+	callable, ok := value.(GoLoxCallable)
+	if !ok {
+		return nil, argumentError(function, name, "a function", value)
+	}
+	return callable, nil
+}
+
 type Clock struct{}
 
-func (c *Clock) arity() int { return 0 }
+func (c *Clock) arity() Arity { return exactArity(0) }
 
 func (c *Clock) call(interp *Interpreter, args []any) (any, error) {
 	currentTime := time.Now()
@@ -21,20 +139,59 @@ func (c *Clock) String() string {
 
 type ReadFile struct{}
 
-func (c *ReadFile) arity() int { return 1 }
+func (c *ReadFile) arity() Arity { return exactArity(1) }
 
 func (c *ReadFile) call(interp *Interpreter, args []any) (any, error) {
-	path, ok := args[0].(string)
-	if !ok {
-		return nil, fmt.Errorf("the first argument must be a string: %s", args[0])
+	path, err := pathArgument(interp, "readFile", args[0])
+	if err != nil {
+		return nil, err
 	}
 	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileError("readFile", err)
+	}
+	err = interp.checkSize(0, len(bytes))
 	if err != nil {
 		return nil, err
 	}
-	return bytes, nil
+	return string(bytes), nil
 }
 
 func (c *ReadFile) String() string {
 	return "<native function>"
 }
+
+// Resolve the path of a file accessed by a native, the relative paths are
+// relative to the root of the file system natives. The path must not lead
+// outside of the root, even through a symbolic link. The file may not exist
+// but its directory must exist.
+func allowedPath(interp *Interpreter, path string) (string, error) {
+	if interp.options.FileSystemRoot == "" {
+		return path, nil
+	}
+	root, err := filepath.Abs(interp.options.FileSystemRoot)
+	if err != nil {
+		return "", err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		var directory string
+		directory, err = filepath.EvalSymlinks(filepath.Dir(path))
+		resolved = filepath.Join(directory, filepath.Base(path))
+	}
+	if err != nil {
+		return "", err
+	}
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", NewAccessError(0, fmt.Sprintf("access to '%s' outside of '%s' denied", path, root))
+	}
+	return resolved, nil
+}
@@ -0,0 +1,44 @@
+package golox
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegerArgument(t *testing.T) {
+	for _, value := range []float64{0, -3, 42, maxExactInteger, -maxExactInteger} {
+		integer, err := integerArgument("test", "value", value)
+		if err != nil || float64(integer) != value {
+			t.Errorf("integerArgument(%v) = %d, %v", value, integer, err)
+		}
+	}
+	errors := map[any]string{
+		"1":                          "test: the argument 'value' must be an integer, got string",
+		1.5:                          "test: the argument 'value' must be an integer, got number",
+		math.Inf(1):                  "test: the argument 'value' must be an integer, got number",
+		math.NaN():                   "test: the argument 'value' must be an integer, got number",
+		1e19:                         "test: the argument 'value' is out of range",
+		-1e300:                       "test: the argument 'value' is out of range",
+		float64(maxExactInteger + 2): "test: the argument 'value' is out of range",
+	}
+	for value, message := range errors {
+		_, err := integerArgument("test", "value", value)
+		if err == nil || err.Error() != message {
+			t.Errorf("integerArgument(%v) fails with %v, want %q", value, err, message)
+		}
+	}
+}
+
+func TestTypeNameOfCaughtError(t *testing.T) {
+	err, ok := caughtError(NewThrowError(1, "boom", "boom"))
+	if !ok {
+		t.Fatal("the thrown value is not caught")
+	}
+	if name := typeName(err); name != "error" {
+		t.Errorf("typeName = %q", name)
+	}
+	// A caught error prints as its kind and message, without the line
+	if err.String() != "Error: boom" {
+		t.Errorf("String = %q", err.String())
+	}
+}
@@ -0,0 +1,324 @@
+package golox
+
+import (
+	"errors"
+)
+
+// Optimizer rewrites the AST before its interpretation: the expressions only
+// made of literals are folded into a single literal and the branches that can
+// never be executed are removed.
+type Optimizer struct {
+	// Interpreter used to compute the folded values, this way the folding
+	// follows exactly the semantics of the runtime
+	interp *Interpreter
+}
+
+func NewOptimizer() *Optimizer {
+	return &Optimizer{interp: NewInterpreter()}
+}
+
+func (o *Optimizer) Optimize(statements []Stmt[any]) ([]Stmt[any], error) {
+	optimized := make([]Stmt[any], 0, len(statements))
+	for _, statement := range statements {
+		stmt, err := o.optimizeStmt(statement)
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			optimized = append(optimized, stmt)
+		}
+	}
+	return optimized, nil
+}
+
+func (o *Optimizer) visitBlockStmt(stmt *Block[any]) (any, error) {
+	statements, err := o.Optimize(stmt.statements)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlock(statements), nil
+}
+
+func (o *Optimizer) visitExpressionStmt(stmt *Expression[any]) (any, error) {
+	expression, err := o.optimizeExpr(stmt.expression)
+	if err != nil {
+		return nil, err
+	}
+	return NewExpression(expression), nil
+}
+
+func (o *Optimizer) visitIfStmt(stmt *If[any]) (any, error) {
+	condition, err := o.optimizeExpr(stmt.condition)
+	if err != nil {
+		return nil, err
+	}
+	if literal, ok := condition.(*Literal[any]); ok {
+		// Only the branch that is always taken is kept
+		if o.interp.isTruthy(literal.value) {
+			return o.optimizeStmt(stmt.thenBranch)
+		} else if stmt.elseBranch != nil {
+			return o.optimizeStmt(stmt.elseBranch)
+		}
+		return nil, nil
+	}
+	thenBranch, err := o.optimizeStmt(stmt.thenBranch)
+	if err != nil {
+		return nil, err
+	}
+	if thenBranch == nil {
+		thenBranch = NewBlock([]Stmt[any]{})
+	}
+	var elseBranch Stmt[any]
+	if stmt.elseBranch != nil {
+		elseBranch, err = o.optimizeStmt(stmt.elseBranch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewIf(stmt.keyword, condition, thenBranch, elseBranch), nil
+}
+
+func (o *Optimizer) visitImportStmt(stmt *Import[any]) (any, error) {
+	return stmt, nil
+}
+
+func (o *Optimizer) visitPrintStmt(stmt *Print[any]) (any, error) {
+	expression, err := o.optimizeExpr(stmt.expression)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrint(stmt.keyword, expression), nil
+}
+
+func (o *Optimizer) visitReturnStmt(stmt *Return[any]) (any, error) {
+	if stmt.value == nil {
+		return stmt, nil
+	}
+	value, err := o.optimizeExpr(stmt.value)
+	if err != nil {
+		return nil, err
+	}
+	return NewReturn(stmt.keyword, value), nil
+}
+
+func (o *Optimizer) visitThrowStmt(stmt *Throw[any]) (any, error) {
+	value, err := o.optimizeExpr(stmt.value)
+	if err != nil {
+		return nil, err
+	}
+	return NewThrow(stmt.keyword, value), nil
+}
+
+func (o *Optimizer) visitTryStmt(stmt *Try[any]) (any, error) {
+	// The clauses are blocks, they are kept even if they are empty
+	body, err := o.visitBlockStmt(stmt.body.(*Block[any]))
+	if err != nil {
+		return nil, err
+	}
+	var handler, finalizer any
+	if stmt.handler != nil {
+		handler, err = o.visitBlockStmt(stmt.handler.(*Block[any]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if stmt.finalizer != nil {
+		finalizer, err = o.visitBlockStmt(stmt.finalizer.(*Block[any]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewTry(stmt.keyword, body.(Stmt[any]), stmt.name, optionalStmt(handler), optionalStmt(finalizer)), nil
+}
+
+func (o *Optimizer) visitVarStmt(stmt *Var[any]) (any, error) {
+	if stmt.initializer == nil {
+		return stmt, nil
+	}
+	initializer, err := o.optimizeExpr(stmt.initializer)
+	if err != nil {
+		return nil, err
+	}
+	return NewVar(stmt.name, initializer), nil
+}
+
+func (o *Optimizer) visitWhileStmt(stmt *While[any]) (any, error) {
+	condition, err := o.optimizeExpr(stmt.condition)
+	if err != nil {
+		return nil, err
+	}
+	if literal, ok := condition.(*Literal[any]); ok && !o.interp.isTruthy(literal.value) {
+		// The body is never executed
+		return nil, nil
+	}
+	body, err := o.optimizeStmt(stmt.body)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		body = NewBlock([]Stmt[any]{})
+	}
+	return NewWhile(stmt.keyword, condition, body), nil
+}
+
+func (o *Optimizer) visitAssignExpr(expr *Assign[any]) (any, error) {
+	value, err := o.optimizeExpr(expr.value)
+	if err != nil {
+		return nil, err
+	}
+	return NewAssign(expr.name, value), nil
+}
+
+func (o *Optimizer) visitBinaryExpr(expr *Binary[any]) (any, error) {
+	left, err := o.optimizeExpr(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := o.optimizeExpr(expr.right)
+	if err != nil {
+		return nil, err
+	}
+	return o.fold(NewBinary(left, expr.operator, right), left, right), nil
+}
+
+func (o *Optimizer) visitCallExpr(expr *Call[any]) (any, error) {
+	callee, err := o.optimizeExpr(expr.callee)
+	if err != nil {
+		return nil, err
+	}
+	var arguments []Expr[any]
+	for _, argument := range expr.arguments {
+		arg, err := o.optimizeExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, arg)
+	}
+	return NewCall(callee, expr.paren, arguments), nil
+}
+
+func (o *Optimizer) visitFunctionExpr(expr *Function[any]) (any, error) {
+	defaults := make([]Expr[any], len(expr.defaults))
+	for i, value := range expr.defaults {
+		if value == nil {
+			continue
+		}
+		optimized, err := o.optimizeExpr(value)
+		if err != nil {
+			return nil, err
+		}
+		defaults[i] = optimized
+	}
+	body, err := o.Optimize(expr.body)
+	if err != nil {
+		return nil, err
+	}
+	return NewFunction(expr.keyword, expr.params, defaults, expr.rest, body), nil
+}
+
+func (o *Optimizer) visitGetExpr(expr *Get[any]) (any, error) {
+	object, err := o.optimizeExpr(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	return NewGet(object, expr.name), nil
+}
+
+func (o *Optimizer) visitGroupingExpr(expr *Grouping[any]) (any, error) {
+	expression, err := o.optimizeExpr(expr.expression)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := expression.(*Literal[any]); ok {
+		return expression, nil
+	}
+	return NewGrouping(expression), nil
+}
+
+func (o *Optimizer) visitLiteralExpr(expr *Literal[any]) (any, error) {
+	return expr, nil
+}
+
+func (o *Optimizer) visitLogicalExpr(expr *Logical[any]) (any, error) {
+	left, err := o.optimizeExpr(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := o.optimizeExpr(expr.right)
+	if err != nil {
+		return nil, err
+	}
+	if literal, ok := left.(*Literal[any]); ok {
+		// The short-circuit is known at compile time: either the left value
+		// is the result or the right expression is evaluated
+		isTruthy := o.interp.isTruthy(literal.value)
+		if (expr.operator.tokenType == AND && !isTruthy) || (expr.operator.tokenType == OR && isTruthy) {
+			return left, nil
+		}
+		return right, nil
+	}
+	return NewLogical(left, expr.operator, right), nil
+}
+
+func (o *Optimizer) visitUnaryExpr(expr *Unary[any]) (any, error) {
+	right, err := o.optimizeExpr(expr.right)
+	if err != nil {
+		return nil, err
+	}
+	return o.fold(NewUnary(expr.operator, right), right), nil
+}
+
+func (o *Optimizer) visitVariableExpr(expr *Variable[any]) (any, error) {
+	return expr, nil
+}
+
+// Replace the expression by its value if all its operands are literals.
+// If the evaluation fails the expression is kept as is so the error is raised
+// at runtime with the same line.
+func (o *Optimizer) fold(expr Expr[any], operands ...Expr[any]) Expr[any] {
+	for _, operand := range operands {
+		if _, ok := operand.(*Literal[any]); !ok {
+			return expr
+		}
+	}
+	value, err := o.interp.evaluate(expr)
+	if err != nil {
+		return expr
+	}
+	return NewLiteral[any](value)
+}
+
+// Convert an optional statement returned by a visit method
+func optionalStmt(value any) Stmt[any] {
+	if value == nil {
+		return nil
+	}
+	return value.(Stmt[any])
+}
+
+func (o *Optimizer) optimizeStmt(stmt Stmt[any]) (Stmt[any], error) {
+	value, err := stmt.accept(o)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	optimized, ok := value.(Stmt[any])
+	if !ok {
+		return nil, errors.New("the optimization of a statement failed")
+	}
+	return optimized, nil
+}
+
+func (o *Optimizer) optimizeExpr(expr Expr[any]) (Expr[any], error) {
+	value, err := expr.accept(o)
+	if err != nil {
+		return nil, err
+	}
+	optimized, ok := value.(Expr[any])
+	if !ok {
+		return nil, errors.New("the optimization of an expression failed")
+	}
+	return optimized, nil
+}
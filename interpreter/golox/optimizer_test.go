@@ -0,0 +1,135 @@
+package golox
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// Interpret the statements of a script and get its output
+func interpretTestStatements(statements []Stmt[any]) (string, error) {
+	interpreter := NewInterpreter()
+	output := &strings.Builder{}
+	interpreter.SetOutput(output)
+	_, err := interpreter.interpret(context.Background(), statements, false)
+	return output.String(), err
+}
+
+// The optimized script prints the same output and fails with the same error
+// at the same line as the original script
+func TestOptimizer(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// AST of the optimized script, not checked if empty
+		optimized string
+		output    string
+		// Error of both scripts, no error if empty
+		err string
+	}{
+		{
+			name:      "arithmetic",
+			source:    "print 1 + 2 * 3;",
+			optimized: "(print 7)\n",
+			output:    "7.000000\n",
+		},
+		{
+			name:      "concatenation",
+			source:    "print \"a\" + \"b\";",
+			optimized: "(print ab)\n",
+			output:    "ab\n",
+		},
+		{
+			name:      "unary and grouping",
+			source:    "print -(1 + 2);\nprint !(1 < 2);",
+			optimized: "(print -3)\n(print false)\n",
+			output:    "-3.000000\nfalse\n",
+		},
+		{
+			name:      "equality",
+			source:    "print 1 == 1;",
+			optimized: "(print true)\n",
+			output:    "true\n",
+		},
+		{
+			name:      "failing folding",
+			source:    "print 1;\nprint \"a\" - 1;",
+			optimized: "(print 1)\n(print (- a 1))\n",
+			output:    "1.000000\n",
+			err:       "[line 2] RUNTIME ERROR: Left operand must be a number",
+		},
+		{
+			name:      "failing folding in the branch taken",
+			source:    "if (1 > 2) { print 1; } else {\n  print -\"a\";\n}",
+			optimized: "{\n(print (- a))\n}\n",
+			err:       "[line 2] RUNTIME ERROR: Operand must be a number",
+		},
+		{
+			name:      "variable operand",
+			source:    "var x = 1;\nprint x + (2 * 3);",
+			optimized: "(var x 1)\n(print (+ x 6))\n",
+			output:    "7.000000\n",
+		},
+		{
+			name:      "dead else branch",
+			source:    "if (false) print 1; else print 2;",
+			optimized: "(print 2)\n",
+			output:    "2.000000\n",
+		},
+		{
+			name:      "dead if statement",
+			source:    "if (nil) print 1;\nprint 2;",
+			optimized: "(print 2)\n",
+			output:    "2.000000\n",
+		},
+		{
+			name:      "dead while statement",
+			source:    "while (false) print 1;\nprint 2;",
+			optimized: "(print 2)\n",
+			output:    "2.000000\n",
+		},
+		{
+			name:      "short-circuit",
+			source:    "print false and missing;\nprint nil or \"default\";",
+			optimized: "(print false)\n(print default)\n",
+			output:    "false\ndefault\n",
+		},
+		{
+			name:      "logical with a variable",
+			source:    "var x = 1;\nprint true and x;",
+			optimized: "(var x 1)\n(print x)\n",
+			output:    "1.000000\n",
+		},
+		{
+			name:   "function",
+			source: "var f = fun (a = 1 + 1) { return a * (2 + 3); };\nprint f();",
+			output: "10.000000\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			optimized, err := NewOptimizer().Optimize(statements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ast := NewAstPrinter().Print(optimized); test.optimized != "" && ast != test.optimized {
+				t.Errorf("%q is optimized to %q, want %q", test.source, ast, test.optimized)
+			}
+			for _, script := range [][]Stmt[any]{statements, optimized} {
+				output, err := interpretTestStatements(script)
+				message := ""
+				if err != nil {
+					message = err.Error()
+				}
+				if output != test.output || message != test.err {
+					t.Errorf("%q printed %q and failed with %q, want %q and %q",
+						test.source, output, message, test.output, test.err)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package golox
 
 import (
+	"errors"
 	"fmt"
 )
 
 type Parser[T any] struct {
 	tokens  []*Token
 	current int
+	// Number of nested functions being parsed, a return is only valid in a
+	// function
+	functions int
+	// Skip the statements with a syntax error instead of stopping at the first
+	// error
+	recovering bool
+	errors     []*SyntaxError
 }
 
 func NewParser[T any](tokensCapacity int) *Parser[T] {
@@ -30,13 +38,85 @@ func (p *Parser[T]) Parse() ([]Stmt[T], error) {
 	return statements, nil
 }
 
+// Parse all the statements that are valid, e.g. to analyze a script being
+// edited. After a syntax error the parser skips to the next statement. The
+// error gives all the syntax errors found.
+func (p *Parser[T]) ParseRecovering() ([]Stmt[T], error) {
+	p.recovering = true
+	statements := make([]Stmt[T], 0, 100)
+	for !p.isAtEnd() {
+		stmt, err := p.declaration()
+		if err != nil {
+			p.addError(err)
+			p.synchronize()
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	if len(p.errors) > 0 {
+		return statements, NewSyntaxErrors(p.errors...)
+	}
+	return statements, nil
+}
+
+func (p *Parser[T]) addError(err error) {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		p.errors = append(p.errors, syntaxErr)
+	}
+}
+
 func (p *Parser[T]) declaration() (Stmt[T], error) {
 	if p.match(VAR) {
 		return p.varDeclaration()
+	} else if p.match(IMPORT, FROM) {
+		return p.importDeclaration()
 	}
 	return p.statement()
 }
 
+// Parse an import of a whole module, `import "path" as name;`, or of some
+// names of a module, `from "path" import name, other;`
+func (p *Parser[T]) importDeclaration() (Stmt[T], error) {
+	keyword := p.previous()
+	path, err := p.consume(STRING, fmt.Sprintf("expect the path of the module after '%s'", keyword.lexeme))
+	if err != nil {
+		return nil, err
+	}
+	var alias *Token
+	var names []*Token
+	if keyword.tokenType == IMPORT {
+		_, err = p.consume(AS, "expect 'as' after the path of the module")
+		if err != nil {
+			return nil, err
+		}
+		alias, err = p.consume(IDENTIFIER, "expect the name of the module after 'as'")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err = p.consume(IMPORT, "expect 'import' after the path of the module")
+		if err != nil {
+			return nil, err
+		}
+		for {
+			name, err := p.consume(IDENTIFIER, "expect the name of a variable of the module")
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	_, err = p.consume(SEMICOLON, "expect a ';' at the end of an import")
+	if err != nil {
+		return nil, err
+	}
+	return NewImport[T](keyword, path, alias, names), nil
+}
+
 func (p *Parser[T]) statement() (Stmt[T], error) {
 	if p.match(PRINT) {
 		return p.printStatement()
@@ -46,6 +126,12 @@ func (p *Parser[T]) statement() (Stmt[T], error) {
 		return p.whileStatement()
 	} else if p.match(FOR) {
 		return p.forStatement()
+	} else if p.match(THROW) {
+		return p.throwStatement()
+	} else if p.match(TRY) {
+		return p.tryStatement()
+	} else if p.match(RETURN) {
+		return p.returnStatement()
 	} else if p.match(LEFT_BRACE) {
 		statements, err := p.block()
 		if err != nil {
@@ -57,6 +143,7 @@ func (p *Parser[T]) statement() (Stmt[T], error) {
 }
 
 func (p *Parser[T]) forStatement() (Stmt[T], error) {
+	keyword := p.previous()
 	_, err := p.consume(LEFT_PAREN, "Missing parenthesis before the clauses of the for statement")
 	if err != nil {
 		return nil, err
@@ -77,7 +164,10 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 			return nil, err
 		}
 	}
-	p.consume(SEMICOLON, "expect a ';' at the end of a condition")
+	_, err = p.consume(SEMICOLON, "expect a ';' at the end of a condition")
+	if err != nil {
+		return nil, err
+	}
 	var increment Expr[T]
 	if !p.check(RIGHT_PAREN) {
 		increment, err = p.expression()
@@ -85,7 +175,10 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 			return nil, err
 		}
 	}
-	p.consume(RIGHT_PAREN, "expect a ')' at the end of a condition")
+	_, err = p.consume(RIGHT_PAREN, "expect a ')' at the end of a condition")
+	if err != nil {
+		return nil, err
+	}
 	body, err := p.statement()
 	if err != nil {
 		return nil, err
@@ -97,7 +190,7 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 		})
 	}
 	if condition != nil {
-		body = NewWhile(condition, body)
+		body = NewWhile(keyword, condition, body)
 	}
 	if initializer != nil {
 		body = NewBlock([]Stmt[T]{initializer, body})
@@ -105,7 +198,95 @@ func (p *Parser[T]) forStatement() (Stmt[T], error) {
 	return body, nil
 }
 
+func (p *Parser[T]) throwStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(SEMICOLON, "expect ';' at the end of a statement")
+	if err != nil {
+		return nil, err
+	}
+	return NewThrow(keyword, value), nil
+}
+
+func (p *Parser[T]) returnStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	if p.functions == 0 {
+		return nil, NewSyntaxError(keyword.line, "can't return outside of a function")
+	}
+	var value Expr[T]
+	if !p.check(SEMICOLON) {
+		var err error
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err := p.consume(SEMICOLON, "expect ';' at the end of a statement")
+	if err != nil {
+		return nil, err
+	}
+	return NewReturn(keyword, value), nil
+}
+
+// Parse `try { ... } catch (name) { ... } finally { ... }`, the catch or the
+// finally clause can be omitted but not both
+func (p *Parser[T]) tryStatement() (Stmt[T], error) {
+	keyword := p.previous()
+	body, err := p.blockStatement("expect a '{' after 'try'")
+	if err != nil {
+		return nil, err
+	}
+	var name *Token
+	var handler Stmt[T]
+	if p.match(CATCH) {
+		_, err = p.consume(LEFT_PAREN, "expect a '(' after 'catch'")
+		if err != nil {
+			return nil, err
+		}
+		name, err = p.consume(IDENTIFIER, "expect the name of the error after 'catch ('")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(RIGHT_PAREN, "expect a ')' after the name of the error")
+		if err != nil {
+			return nil, err
+		}
+		handler, err = p.blockStatement("expect a '{' after the catch clause")
+		if err != nil {
+			return nil, err
+		}
+	}
+	var finalizer Stmt[T]
+	if p.match(FINALLY) {
+		finalizer, err = p.blockStatement("expect a '{' after 'finally'")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if handler == nil && finalizer == nil {
+		return nil, NewSyntaxError(p.peek().line, "expect 'catch' or 'finally' after the try block")
+	}
+	return NewTry(keyword, body, name, handler, finalizer), nil
+}
+
+// Parse a block required by a statement
+func (p *Parser[T]) blockStatement(expectMessage string) (Stmt[T], error) {
+	_, err := p.consume(LEFT_BRACE, expectMessage)
+	if err != nil {
+		return nil, err
+	}
+	statements, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return NewBlock(statements), nil
+}
+
 func (p *Parser[T]) whileStatement() (Stmt[T], error) {
+	keyword := p.previous()
 	_, err := p.consume(LEFT_PAREN, "Missing parenthesis before the condition of the while statement")
 	if err != nil {
 		return nil, err
@@ -122,10 +303,11 @@ func (p *Parser[T]) whileStatement() (Stmt[T], error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewWhile(condition, body), nil
+	return NewWhile(keyword, condition, body), nil
 }
 
 func (p *Parser[T]) ifStatement() (Stmt[T], error) {
+	keyword := p.previous()
 	_, err := p.consume(LEFT_PAREN, "Missing parenthesis before the condition of the if statement")
 	if err != nil {
 		return nil, err
@@ -149,7 +331,7 @@ func (p *Parser[T]) ifStatement() (Stmt[T], error) {
 			return nil, err
 		}
 	}
-	return NewIf(condition, thenBranch, elseBranch), nil
+	return NewIf(keyword, condition, thenBranch, elseBranch), nil
 }
 
 func (p *Parser[T]) block() ([]Stmt[T], error) {
@@ -159,7 +341,14 @@ func (p *Parser[T]) block() ([]Stmt[T], error) {
 			break
 		}
 		statement, err := p.declaration()
-		if err != nil {
+		if err != nil && p.recovering {
+			// The statements of the block after the error are kept
+			p.addError(err)
+			if !p.check(RIGHT_BRACE) {
+				p.synchronize()
+			}
+			continue
+		} else if err != nil {
 			return statements, err
 		}
 		statements = append(statements, statement)
@@ -172,6 +361,7 @@ func (p *Parser[T]) block() ([]Stmt[T], error) {
 }
 
 func (p *Parser[T]) printStatement() (Stmt[T], error) {
+	keyword := p.previous()
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -180,7 +370,7 @@ func (p *Parser[T]) printStatement() (Stmt[T], error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewPrint(expr), nil
+	return NewPrint(keyword, expr), nil
 }
 
 func (p *Parser[T]) expressionStatement() (Stmt[T], error) {
@@ -224,13 +414,7 @@ func (p *Parser[T]) synchronize() {
 			return
 		}
 		switch p.peek().tokenType {
-		case CLASS:
-		case FUN:
-		case FOR:
-		case IF:
-		case WHILE:
-		case PRINT:
-		case RETURN:
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN, RIGHT_BRACE:
 			return
 		}
 		p.next()
@@ -243,6 +427,9 @@ func (p *Parser[T]) expression() (Expr[T], error) {
 
 func (p *Parser[T]) assignment() (Expr[T], error) {
 	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
 	if p.match(EQUAL) {
 		equals := p.previous()
 		expr, ok := expr.(*Variable[T])
@@ -255,7 +442,7 @@ func (p *Parser[T]) assignment() (Expr[T], error) {
 		}
 		return nil, NewSyntaxError(equals.line, "Invalid assignment target.")
 	}
-	return expr, err
+	return expr, nil
 }
 
 func (p *Parser[T]) logicalOr() (Expr[T], error) {
@@ -361,7 +548,7 @@ func (p *Parser[T]) factor() (Expr[T], error) {
 		return nil, err
 	}
 	for {
-		if !p.match(SLASH, STAR) {
+		if !p.match(SLASH, STAR, PERCENT) {
 			break
 		}
 		operator := p.previous()
@@ -393,6 +580,14 @@ func (p *Parser[T]) call() (Expr[T], error) {
 		return nil, err
 	}
 	for {
+		if p.match(DOT) {
+			name, err := p.consume(IDENTIFIER, "expect a name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			callee = NewGet(callee, name)
+			continue
+		}
 		if !p.match(LEFT_PAREN) {
 			break
 		}
@@ -444,6 +639,10 @@ func (p *Parser[T]) primary() (Expr[T], error) {
 		return NewLiteral[T](nil), nil
 	} else if p.match(NUMBER, STRING) {
 		return NewLiteral[T](p.previous().literal), nil
+	} else if p.match(FUN) {
+		return p.function(p.previous())
+	} else if p.check(LEFT_PAREN) && p.isArrowFunction() {
+		return p.function(p.next())
 	} else if p.match(LEFT_PAREN) {
 		expr, err := p.expression()
 		if err != nil {
@@ -466,6 +665,122 @@ func (p *Parser[T]) primary() (Expr[T], error) {
 	return nil, NewSyntaxError(p.peek().line, msg)
 }
 
+// Parse a function expression, `fun (a, b) { ... }`, or an arrow function,
+// `(a, b) => expression` or `(a, b) => { ... }`. The keyword is "fun" or the
+// opening parenthesis of the arrow function.
+func (p *Parser[T]) function(keyword *Token) (Expr[T], error) {
+	if keyword.tokenType == FUN {
+		_, err := p.consume(LEFT_PAREN, "expect a '(' after 'fun'")
+		if err != nil {
+			return nil, err
+		}
+	}
+	params, defaults, rest, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RIGHT_PAREN, "expect a ')' after the parameters")
+	if err != nil {
+		return nil, err
+	}
+	var arrow *Token
+	if keyword.tokenType != FUN {
+		arrow, err = p.consume(ARROW, "expect '=>' after the parameters")
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.functions += 1
+	defer func() { p.functions -= 1 }()
+	if arrow != nil && !p.check(LEFT_BRACE) {
+		// The body of an arrow function can be a single expression
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return NewFunction(keyword, params, defaults, rest, []Stmt[T]{NewReturn(arrow, value)}), nil
+	}
+	_, err = p.consume(LEFT_BRACE, "expect a '{' before the body of the function")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return NewFunction(keyword, params, defaults, rest, body), nil
+}
+
+// Parse the parameters of a function: the names, their default values, nil
+// for the parameters without default, and the rest parameter, `...name`,
+// receiving the additional arguments
+func (p *Parser[T]) parameters() ([]*Token, []Expr[T], *Token, error) {
+	var params []*Token
+	var defaults []Expr[T]
+	var rest *Token
+	names := make(map[string]bool)
+	for !p.check(RIGHT_PAREN) {
+		if len(params) >= 255 {
+			return nil, nil, nil, NewSyntaxError(p.peek().line, "can't have more than 255 parameters")
+		}
+		isRest := p.match(ELLIPSIS)
+		name, err := p.consume(IDENTIFIER, "expect the name of a parameter")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if names[name.lexeme] {
+			return nil, nil, nil, NewSyntaxError(name.line, fmt.Sprintf("duplicate parameter '%s'", name.lexeme))
+		}
+		names[name.lexeme] = true
+		if isRest {
+			rest = name
+			if p.check(COMMA) {
+				return nil, nil, nil, NewSyntaxError(name.line, "the rest parameter must be the last parameter")
+			}
+			break
+		}
+		var value Expr[T]
+		if p.match(EQUAL) {
+			value, err = p.expression()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		} else if len(defaults) > 0 && defaults[len(defaults)-1] != nil {
+			return nil, nil, nil, NewSyntaxError(
+				name.line,
+				fmt.Sprintf("the parameter '%s' must have a default value as the previous parameters", name.lexeme),
+			)
+		}
+		params = append(params, name)
+		defaults = append(defaults, value)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	return params, defaults, rest, nil
+}
+
+// Check if the opening parenthesis at the current position starts the
+// parameters of an arrow function, i.e. its closing parenthesis is followed
+// by '=>'
+func (p *Parser[T]) isArrowFunction() bool {
+	depth := 0
+	for i := p.current; i < len(p.tokens); i++ {
+		switch p.tokens[i].tokenType {
+		case LEFT_PAREN:
+			depth += 1
+		case RIGHT_PAREN:
+			depth -= 1
+			if depth == 0 {
+				return i+1 < len(p.tokens) && p.tokens[i+1].tokenType == ARROW
+			}
+		case EOF:
+			return false
+		}
+	}
+	return false
+}
+
 func (p *Parser[T]) consume(tokenType TokenType, expectMessage string) (*Token, error) {
 	if p.check(tokenType) {
 		return p.next(), nil
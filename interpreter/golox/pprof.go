@@ -0,0 +1,193 @@
+package golox
+
+import (
+	"compress/gzip"
+	"io"
+	"time"
+)
+
+// pprofProfile builds a profile in the format read by "go tool pprof": a
+// gzipped protocol buffer message described in
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+type pprofProfile struct {
+	filename    string
+	sampleTypes [][2]int64
+	samples     []pprofBuffer
+	locations   []pprofBuffer
+	functions   []pprofBuffer
+	strings     []string
+	// Indexes in the table of strings
+	stringIds map[string]int64
+	// Ids of the locations by function and line
+	locationIds map[string]map[int]uint64
+	// Ids of the functions by name
+	functionIds map[string]uint64
+}
+
+// Fields of the messages of the profile
+const (
+	pprofProfileSampleType    = 1
+	pprofProfileSample        = 2
+	pprofProfileLocation      = 4
+	pprofProfileFunction      = 5
+	pprofProfileStringTable   = 6
+	pprofProfileTimeNanos     = 9
+	pprofProfileDurationNanos = 10
+	pprofValueTypeType        = 1
+	pprofValueTypeUnit        = 2
+	pprofSampleLocationId     = 1
+	pprofSampleValue          = 2
+	pprofLocationId           = 1
+	pprofLocationLine         = 4
+	pprofLineFunctionId       = 1
+	pprofLineLine             = 2
+	pprofFunctionId           = 1
+	pprofFunctionName         = 2
+	pprofFunctionSystemName   = 3
+	pprofFunctionFilename     = 4
+)
+
+func newPprofProfile(filename string) *pprofProfile {
+	profile := &pprofProfile{
+		filename:    filename,
+		stringIds:   make(map[string]int64),
+		locationIds: make(map[string]map[int]uint64),
+		functionIds: make(map[string]uint64),
+	}
+	// The first string of the table must be empty
+	profile.stringId("")
+	return profile
+}
+
+func (p *pprofProfile) addSampleType(sampleType string, unit string) {
+	p.sampleTypes = append(p.sampleTypes, [2]int64{p.stringId(sampleType), p.stringId(unit)})
+}
+
+func (p *pprofProfile) addSample(locations []uint64, values ...int64) {
+	var sample pprofBuffer
+	sample.packedUints(pprofSampleLocationId, locations)
+	ints := make([]uint64, len(values))
+	for i, value := range values {
+		ints[i] = uint64(value)
+	}
+	sample.packedUints(pprofSampleValue, ints)
+	p.samples = append(p.samples, sample)
+}
+
+// Get the id of the location of the line in the function, the location is
+// created if needed
+func (p *pprofProfile) location(function string, line int) uint64 {
+	lines, ok := p.locationIds[function]
+	if !ok {
+		lines = make(map[int]uint64)
+		p.locationIds[function] = lines
+	}
+	if id, ok := lines[line]; ok {
+		return id
+	}
+	id := uint64(len(p.locations) + 1)
+	lines[line] = id
+	var lineBuffer pprofBuffer
+	lineBuffer.uint(pprofLineFunctionId, p.function(function))
+	lineBuffer.uint(pprofLineLine, uint64(line))
+	var location pprofBuffer
+	location.uint(pprofLocationId, id)
+	location.message(pprofLocationLine, lineBuffer)
+	p.locations = append(p.locations, location)
+	return id
+}
+
+// Get the id of the function, the function is created if needed
+func (p *pprofProfile) function(name string) uint64 {
+	if id, ok := p.functionIds[name]; ok {
+		return id
+	}
+	id := uint64(len(p.functions) + 1)
+	p.functionIds[name] = id
+	var function pprofBuffer
+	function.uint(pprofFunctionId, id)
+	function.uint(pprofFunctionName, uint64(p.stringId(name)))
+	function.uint(pprofFunctionSystemName, uint64(p.stringId(name)))
+	function.uint(pprofFunctionFilename, uint64(p.stringId(p.filename)))
+	p.functions = append(p.functions, function)
+	return id
+}
+
+func (p *pprofProfile) stringId(value string) int64 {
+	if id, ok := p.stringIds[value]; ok {
+		return id
+	}
+	id := int64(len(p.strings))
+	p.strings = append(p.strings, value)
+	p.stringIds[value] = id
+	return id
+}
+
+func (p *pprofProfile) write(writer io.Writer, start time.Time, duration time.Duration) error {
+	var profile pprofBuffer
+	for _, sampleType := range p.sampleTypes {
+		var valueType pprofBuffer
+		valueType.uint(pprofValueTypeType, uint64(sampleType[0]))
+		valueType.uint(pprofValueTypeUnit, uint64(sampleType[1]))
+		profile.message(pprofProfileSampleType, valueType)
+	}
+	for _, sample := range p.samples {
+		profile.message(pprofProfileSample, sample)
+	}
+	for _, location := range p.locations {
+		profile.message(pprofProfileLocation, location)
+	}
+	for _, function := range p.functions {
+		profile.message(pprofProfileFunction, function)
+	}
+	for _, value := range p.strings {
+		profile.bytes(pprofProfileStringTable, []byte(value))
+	}
+	profile.uint(pprofProfileTimeNanos, uint64(start.UnixNano()))
+	profile.uint(pprofProfileDurationNanos, uint64(duration.Nanoseconds()))
+	gzipWriter := gzip.NewWriter(writer)
+	_, err := gzipWriter.Write(profile)
+	if err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// pprofBuffer encodes the fields of a protocol buffer message
+type pprofBuffer []byte
+
+func (b *pprofBuffer) varint(value uint64) {
+	for value >= 0x80 {
+		*b = append(*b, byte(value)|0x80)
+		value >>= 7
+	}
+	*b = append(*b, byte(value))
+}
+
+// Encode the number and the wire type of a field
+func (b *pprofBuffer) key(field int, wireType uint64) {
+	b.varint(uint64(field)<<3 | wireType)
+}
+
+func (b *pprofBuffer) uint(field int, value uint64) {
+	b.key(field, 0)
+	b.varint(value)
+}
+
+func (b *pprofBuffer) bytes(field int, value []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(value)))
+	*b = append(*b, value...)
+}
+
+func (b *pprofBuffer) message(field int, message pprofBuffer) {
+	b.bytes(field, message)
+}
+
+func (b *pprofBuffer) packedUints(field int, values []uint64) {
+	var packed pprofBuffer
+	for _, value := range values {
+		packed.varint(value)
+	}
+	b.bytes(field, packed)
+}
@@ -0,0 +1,113 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Natives interacting with the process running the script
+var processNatives = []*NativeFunction{
+	NewNativeFunction("args", 0, nativeArgs),
+	NewNativeFunctionWithArity("input", Arity{min: 0, max: 1}, nativeInput),
+	NewNativeFunction("readLine", 0, nativeReadLine),
+	NewNativeFunctionWithArity("exit", Arity{min: 0, max: 1}, nativeExit),
+}
+
+// Natives accessing the environment variables, registered when the
+// interpreter is allowed to access the environment
+var environmentNatives = []*NativeFunction{
+	NewNativeFunction("getenv", 1, nativeGetenv),
+	NewNativeFunction("setenv", 2, nativeSetenv),
+}
+
+// Get the arguments given to the script on the command line
+func nativeArgs(interp *Interpreter, args []any) (any, error) {
+	arguments := make([]any, len(interp.arguments))
+	for i, argument := range interp.arguments {
+		arguments[i] = argument
+	}
+	return NewLoxList(arguments), nil
+}
+
+// Stop the script, the process exits with the given status or 0. The
+// interpreter unwinds up to the host which decides what to do with the status.
+func nativeExit(interp *Interpreter, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, NewExitError(0)
+	}
+	code, err := integerArgument("exit", "code", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if code < 0 || code > 255 {
+		return nil, fmt.Errorf("exit: the argument 'code' must be between 0 and 255, got %d", code)
+	}
+	return nil, NewExitError(code)
+}
+
+// Get the value of an environment variable, nil if the variable is not set
+func nativeGetenv(interp *Interpreter, args []any) (any, error) {
+	name, err := stringArgument("getenv", "name", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func nativeSetenv(interp *Interpreter, args []any) (any, error) {
+	name, err := stringArgument("setenv", "name", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := stringArgument("setenv", "value", args[1])
+	if err != nil {
+		return nil, err
+	}
+	err = os.Setenv(name, value)
+	if err != nil {
+		return nil, fmt.Errorf("setenv: %w", err)
+	}
+	return nil, nil
+}
+
+// Write the prompt if given and read a line of the input
+func nativeInput(interp *Interpreter, args []any) (any, error) {
+	if len(args) > 0 {
+		prompt, err := stringArgument("input", "prompt", args[0])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(interp.stdout, prompt)
+	}
+	return readLine(interp, "input")
+}
+
+// Read a line of the input
+func nativeReadLine(interp *Interpreter, args []any) (any, error) {
+	return readLine(interp, "readLine")
+}
+
+// Read a line of the input without its line terminator, nil at the end of the
+// input
+func readLine(interp *Interpreter, function string) (any, error) {
+	line, err := interp.input().ReadString('\n')
+	if errors.Is(err, io.EOF) {
+		if line == "" {
+			return nil, nil
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", function, err)
+	}
+	err = interp.checkSize(0, len(line))
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
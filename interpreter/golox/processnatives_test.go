@@ -0,0 +1,86 @@
+package golox
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessNatives(t *testing.T) {
+	t.Setenv("GOLOX_TEST", "value")
+	// Restored at the end of the test
+	t.Setenv("GOLOX_NEW", "")
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Error of the script, no error if empty
+		err string
+		// Exit status of the process running the script
+		code int
+	}{
+		{
+			name:   "getenv",
+			source: "print getenv(\"GOLOX_TEST\");\nprint getenv(\"GOLOX_MISSING_VARIABLE\");",
+			output: "value\nnil\n",
+		},
+		{
+			name:   "setenv",
+			source: "setenv(\"GOLOX_NEW\", \"new\");\nprint getenv(\"GOLOX_NEW\");",
+			output: "new\n",
+		},
+		{
+			name:   "exit without status",
+			source: "print 1;\nexit();\nprint 2;",
+			output: "1.000000\n",
+			err:    "exit with status 0",
+		},
+		{
+			name: "exit through a try statement",
+			source: "var f = fun () { exit(3); };\ntry {\n  f();\n} catch (e) {\n  print \"caught\";\n" +
+				"} finally {\n  print \"finally\";\n}\nprint 2;",
+			output: "finally\n",
+			err:    "exit with status 3",
+			code:   3,
+		},
+		{
+			name:   "exit status out of range",
+			source: "exit(256);",
+			err:    "[line 1] RUNTIME ERROR: exit: the argument 'code' must be between 0 and 255, got 256",
+			code:   70,
+		},
+		{
+			name:   "exit status not integer",
+			source: "exit(1.5);",
+			err:    "[line 1] RUNTIME ERROR: exit: the argument 'code' must be an integer, got number",
+			code:   70,
+		},
+		{
+			name:   "invalid variable name",
+			source: "setenv(\"A=B\", \"x\");",
+			err:    "[line 1] RUNTIME ERROR: setenv: setenv: invalid argument",
+			code:   70,
+		},
+		{
+			name:   "variable name not a string",
+			source: "print getenv(1);",
+			err:    "[line 1] RUNTIME ERROR: getenv: the argument 'name' must be a string, got number",
+			code:   70,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := interpretTestScript(t, test.source, nil)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			if output != test.output || message != test.err || exitCode(err) != test.code {
+				t.Errorf("%q printed %q and failed with %q (status %d), want %q and %q (status %d)",
+					test.source, output, message, exitCode(err), test.output, test.err, test.code)
+			}
+		})
+	}
+	if value := os.Getenv("GOLOX_NEW"); value != "new" {
+		t.Errorf("setenv set the variable to %q", value)
+	}
+}
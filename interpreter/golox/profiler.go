@@ -0,0 +1,222 @@
+package golox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the pseudo function executing the top-level statements
+const profilerMain = "main"
+
+// Profiler measures where the time is spent while executing a script. It is
+// plugged in the interpreter as an ExecutionHook and a CallHook.
+//
+// The time elapsed between two events (start of a statement, start or end of
+// a call) is charged to the line being executed in the current call stack.
+type Profiler struct {
+	path      string
+	isStopped bool
+	start     time.Time
+	// Time of the last event
+	lastEvent time.Time
+	// Line being executed
+	line  int
+	stack []*profilerFrame
+	// Number of executions of the statements of each line
+	lineCounts map[int]int
+	// Time charged to each line
+	lineTimes map[int]time.Duration
+	functions map[string]*profilerFunction
+	// Time charged to each call stack, by key of the stack
+	samples map[string]*profilerSample
+}
+
+type profilerFrame struct {
+	function *profilerFunction
+	// Line of the caller where the call happened
+	callLine int
+	start    time.Time
+}
+
+type profilerFunction struct {
+	name  string
+	calls int
+	// Time spent in the function excluding the functions it called
+	self time.Duration
+	// Time spent in the function including the functions it called
+	cumulative time.Duration
+	// Number of calls in progress, only the outermost call of a recursion is
+	// counted in the cumulative time
+	active int
+}
+
+type profilerSample struct {
+	// Functions and lines of the stack, from the caller to the callee
+	functions []string
+	lines     []int
+	count     int
+	duration  time.Duration
+}
+
+func NewProfiler(path string) *Profiler {
+	now := time.Now()
+	main := &profilerFunction{name: profilerMain, calls: 1, active: 1}
+	return &Profiler{
+		path:       path,
+		start:      now,
+		lastEvent:  now,
+		stack:      []*profilerFrame{{function: main, start: now}},
+		lineCounts: make(map[int]int),
+		lineTimes:  make(map[int]time.Duration),
+		functions:  map[string]*profilerFunction{profilerMain: main},
+		samples:    make(map[string]*profilerSample),
+	}
+}
+
+func (p *Profiler) beforeExecute(interp *Interpreter, stmt Stmt[any]) error {
+	line := stmtLine(stmt)
+	if line == 0 {
+		return nil
+	}
+	p.charge(time.Now())
+	p.line = line
+	p.lineCounts[line] += 1
+	return nil
+}
+
+func (p *Profiler) beforeCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable) error {
+	now := time.Now()
+	p.charge(now)
+	name := callable.String()
+	if variable, ok := expr.callee.(*Variable[any]); ok {
+		name = variable.name.lexeme
+	}
+	function, ok := p.functions[name]
+	if !ok {
+		function = &profilerFunction{name: name}
+		p.functions[name] = function
+	}
+	function.calls += 1
+	function.active += 1
+	p.stack = append(p.stack, &profilerFrame{function: function, callLine: p.line, start: now})
+	return nil
+}
+
+func (p *Profiler) afterCall(interp *Interpreter, expr *Call[any], callable GoLoxCallable) {
+	now := time.Now()
+	p.charge(now)
+	frame := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	frame.function.active -= 1
+	if frame.function.active == 0 {
+		frame.function.cumulative += now.Sub(frame.start)
+	}
+	// The execution continues in the line of the caller
+	p.line = frame.callLine
+}
+
+// Charge the time elapsed since the last event to the current line and stack
+func (p *Profiler) charge(now time.Time) {
+	elapsed := now.Sub(p.lastEvent)
+	p.lastEvent = now
+	if p.line == 0 {
+		return
+	}
+	p.lineTimes[p.line] += elapsed
+	p.stack[len(p.stack)-1].function.self += elapsed
+	functions := make([]string, len(p.stack))
+	lines := make([]int, len(p.stack))
+	for i, frame := range p.stack {
+		functions[i] = frame.function.name
+		if i+1 < len(p.stack) {
+			lines[i] = p.stack[i+1].callLine
+		} else {
+			lines[i] = p.line
+		}
+	}
+	key := fmt.Sprint(functions, lines)
+	sample, ok := p.samples[key]
+	if !ok {
+		sample = &profilerSample{functions: functions, lines: lines}
+		p.samples[key] = sample
+	}
+	sample.count += 1
+	sample.duration += elapsed
+}
+
+// Stop the measures at the end of the execution
+func (p *Profiler) stop() {
+	if p.isStopped {
+		return
+	}
+	p.isStopped = true
+	now := time.Now()
+	p.charge(now)
+	main := p.functions[profilerMain]
+	main.cumulative = now.Sub(p.start)
+}
+
+// Write a text report of the time spent by line and by function
+func (p *Profiler) WriteReport(writer io.Writer) {
+	p.stop()
+	var builder strings.Builder
+	lines := make([]int, 0, len(p.lineCounts))
+	for line := range p.lineCounts {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if p.lineTimes[lines[i]] != p.lineTimes[lines[j]] {
+			return p.lineTimes[lines[i]] > p.lineTimes[lines[j]]
+		}
+		return lines[i] < lines[j]
+	})
+	builder.WriteString(fmt.Sprintf("Profile of %s (total %s)\n\n", p.path, p.functions[profilerMain].cumulative))
+	builder.WriteString(fmt.Sprintf("%8s %12s %14s\n", "LINE", "EXECUTIONS", "TIME"))
+	for _, line := range lines {
+		builder.WriteString(fmt.Sprintf("%8d %12d %14s\n", line, p.lineCounts[line], p.lineTimes[line]))
+	}
+	functions := make([]*profilerFunction, 0, len(p.functions))
+	for _, function := range p.functions {
+		functions = append(functions, function)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].self != functions[j].self {
+			return functions[i].self > functions[j].self
+		}
+		return functions[i].name < functions[j].name
+	})
+	builder.WriteString(fmt.Sprintf("\n%-20s %8s %14s %14s\n", "FUNCTION", "CALLS", "SELF", "CUMULATIVE"))
+	for _, function := range functions {
+		builder.WriteString(fmt.Sprintf(
+			"%-20s %8d %14s %14s\n", function.name, function.calls, function.self, function.cumulative,
+		))
+	}
+	io.WriteString(writer, builder.String())
+}
+
+// Write the profile in the format of pprof, the samples are the call stacks
+// with the number of events and the time charged to them
+func (p *Profiler) WritePprof(writer io.Writer) error {
+	p.stop()
+	profile := newPprofProfile(p.path)
+	profile.addSampleType("events", "count")
+	profile.addSampleType("time", "nanoseconds")
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sample := p.samples[key]
+		// The locations of pprof start from the callee
+		locations := make([]uint64, 0, len(sample.functions))
+		for i := len(sample.functions) - 1; i >= 0; i-- {
+			locations = append(locations, profile.location(sample.functions[i], sample.lines[i]))
+		}
+		profile.addSample(locations, int64(sample.count), sample.duration.Nanoseconds())
+	}
+	return profile.write(writer, p.start, p.lastEvent.Sub(p.start))
+}
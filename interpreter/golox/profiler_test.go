@@ -0,0 +1,98 @@
+package golox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// The executions of the lines and the calls of the functions are counted, the
+// times are not checked
+func TestProfiler(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		lines  map[int]int
+		calls  map[string]int
+	}{
+		{
+			name:   "loop",
+			source: "var i = 0;\nwhile (i < 3) {\n  i = i + 1;\n}",
+			lines:  map[int]int{1: 1, 2: 1, 3: 3},
+			calls:  map[string]int{"main": 1},
+		},
+		{
+			name: "recursion and natives",
+			source: "var fact = fun (n) {\n  if (n <= 1) return 1;\n  return n * fact(n - 1);\n};\n" +
+				"print fact(3);\nprint len(\"ab\") + len(\"c\");",
+			lines: map[int]int{1: 1, 2: 4, 3: 2, 5: 1, 6: 1},
+			calls: map[string]int{"main": 1, "fact": 3, "len": 2},
+		},
+		{
+			name:   "runtime error",
+			source: "print 1;\nprint -\"a\";\nprint 3;",
+			lines:  map[int]int{1: 1, 2: 1},
+			calls:  map[string]int{"main": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := NewGoLox().parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interpreter := NewInterpreter()
+			interpreter.SetOutput(io.Discard)
+			profiler := NewProfiler("test.golox")
+			interpreter.SetExecutionHook(profiler)
+			interpreter.interpret(context.Background(), statements, false)
+
+			if fmt.Sprint(profiler.lineCounts) != fmt.Sprint(test.lines) {
+				t.Errorf("the lines are executed %v times, want %v", profiler.lineCounts, test.lines)
+			}
+			calls := make(map[string]int)
+			for name, function := range profiler.functions {
+				calls[name] = function.calls
+			}
+			if fmt.Sprint(calls) != fmt.Sprint(test.calls) {
+				t.Errorf("the functions are called %v times, want %v", calls, test.calls)
+			}
+
+			var report strings.Builder
+			profiler.WriteReport(&report)
+			for line, count := range test.lines {
+				if !regexp.MustCompile(fmt.Sprintf(`(?m)^ +%d +%d +\S+$`, line, count)).MatchString(report.String()) {
+					t.Errorf("the report has no row for the line %d:\n%s", line, report.String())
+				}
+			}
+			for name, count := range test.calls {
+				if !regexp.MustCompile(fmt.Sprintf(`(?m)^%s +%d +\S+ +\S+$`, name, count)).MatchString(report.String()) {
+					t.Errorf("the report has no row for the function %s:\n%s", name, report.String())
+				}
+			}
+
+			var pprof bytes.Buffer
+			if err := profiler.WritePprof(&pprof); err != nil {
+				t.Fatal(err)
+			}
+			reader, err := gzip.NewReader(&pprof)
+			if err != nil {
+				t.Fatalf("the pprof profile is not compressed: %s", err)
+			}
+			profile, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name := range test.calls {
+				if !bytes.Contains(profile, []byte(name)) {
+					t.Errorf("the pprof profile has no function %s", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package golox
+
+// Resolver links each use of a variable to its declaration without executing
+// the code. It is used by the tools analyzing the source code.
+type Resolver struct {
+	// Variables declared in each scope, the first scope is the global scope
+	scopes []map[string]*Token
+	// Native functions defined in the global scope of the interpreter
+	natives map[string]any
+	// Declarations in the order of the source
+	declarations []*Token
+	// Depth of the scope of each declaration, 0 for a global variable
+	depths map[*Token]int
+	// Declaration of each variable read or assigned
+	references map[*Token]*Token
+	// Uses of the variables reading their value, the others are assignments
+	reads map[*Token]bool
+	// Declarations hiding a variable of an enclosing scope
+	shadows map[*Token]*Token
+	// Declarations of the parameters of the functions
+	parameters map[*Token]bool
+	// Variables read or assigned without being declared, excluding natives
+	unresolved []*Token
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		scopes:     []map[string]*Token{make(map[string]*Token)},
+		natives:    NewInterpreter().globals.values,
+		depths:     make(map[*Token]int),
+		references: make(map[*Token]*Token),
+		reads:      make(map[*Token]bool),
+		shadows:    make(map[*Token]*Token),
+		parameters: make(map[*Token]bool),
+	}
+}
+
+func (r *Resolver) Resolve(statements []Stmt[any]) error {
+	for _, statement := range statements {
+		_, err := statement.accept(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the declaration of the variable at the given token, the token can be a
+// declaration or a use of the variable
+func (r *Resolver) declarationOf(token *Token) *Token {
+	if _, ok := r.depths[token]; ok {
+		return token
+	}
+	return r.references[token]
+}
+
+// Get the uses of the variable declared by the given token
+func (r *Resolver) referencesOf(declaration *Token) []*Token {
+	tokens := make([]*Token, 0, 10)
+	for reference, referenceDeclaration := range r.references {
+		if referenceDeclaration == declaration {
+			tokens = append(tokens, reference)
+		}
+	}
+	return tokens
+}
+
+// Check if the name is a native function not redefined by the script
+func (r *Resolver) isNative(name *Token) bool {
+	_, ok := r.natives[name.lexeme]
+	return ok && r.declarationOf(name) == nil
+}
+
+func (r *Resolver) visitBlockStmt(stmt *Block[any]) (any, error) {
+	r.beginScope()
+	err := r.Resolve(stmt.statements)
+	r.endScope()
+	return nil, err
+}
+
+func (r *Resolver) visitExpressionStmt(stmt *Expression[any]) (any, error) {
+	return r.resolveExpr(stmt.expression)
+}
+
+func (r *Resolver) visitIfStmt(stmt *If[any]) (any, error) {
+	_, err := r.resolveExpr(stmt.condition)
+	if err != nil {
+		return nil, err
+	}
+	_, err = stmt.thenBranch.accept(r)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.elseBranch != nil {
+		_, err = stmt.elseBranch.accept(r)
+	}
+	return nil, err
+}
+
+func (r *Resolver) visitImportStmt(stmt *Import[any]) (any, error) {
+	if stmt.alias != nil {
+		r.declare(stmt.alias)
+	}
+	for _, name := range stmt.names {
+		r.declare(name)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) visitPrintStmt(stmt *Print[any]) (any, error) {
+	return r.resolveExpr(stmt.expression)
+}
+
+func (r *Resolver) visitReturnStmt(stmt *Return[any]) (any, error) {
+	if stmt.value != nil {
+		return r.resolveExpr(stmt.value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) visitThrowStmt(stmt *Throw[any]) (any, error) {
+	return r.resolveExpr(stmt.value)
+}
+
+func (r *Resolver) visitTryStmt(stmt *Try[any]) (any, error) {
+	_, err := stmt.body.accept(r)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.handler != nil {
+		// The error is declared in a scope enclosing the block of the handler
+		r.beginScope()
+		r.declare(stmt.name)
+		_, err = stmt.handler.accept(r)
+		r.endScope()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if stmt.finalizer != nil {
+		_, err = stmt.finalizer.accept(r)
+	}
+	return nil, err
+}
+
+func (r *Resolver) visitVarStmt(stmt *Var[any]) (any, error) {
+	if _, ok := stmt.initializer.(*Function[any]); ok {
+		// The body of the function is executed after the declaration of the
+		// variable so the function can call itself
+		r.declare(stmt.name)
+		_, err := r.resolveExpr(stmt.initializer)
+		return nil, err
+	}
+	// The initializer is evaluated before the declaration of the variable so
+	// it can use a variable with the same name of an enclosing scope
+	if stmt.initializer != nil {
+		_, err := r.resolveExpr(stmt.initializer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.declare(stmt.name)
+	return nil, nil
+}
+
+func (r *Resolver) visitWhileStmt(stmt *While[any]) (any, error) {
+	_, err := r.resolveExpr(stmt.condition)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.body.accept(r)
+}
+
+func (r *Resolver) visitAssignExpr(expr *Assign[any]) (any, error) {
+	_, err := r.resolveExpr(expr.value)
+	if err != nil {
+		return nil, err
+	}
+	r.resolveName(expr.name)
+	return nil, nil
+}
+
+func (r *Resolver) visitBinaryExpr(expr *Binary[any]) (any, error) {
+	_, err := r.resolveExpr(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	return r.resolveExpr(expr.right)
+}
+
+func (r *Resolver) visitCallExpr(expr *Call[any]) (any, error) {
+	_, err := r.resolveExpr(expr.callee)
+	if err != nil {
+		return nil, err
+	}
+	for _, argument := range expr.arguments {
+		_, err = r.resolveExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) visitFunctionExpr(expr *Function[any]) (any, error) {
+	// The parameters and the body share the scope of the call
+	r.beginScope()
+	defer r.endScope()
+	for i, param := range expr.params {
+		if expr.defaults[i] != nil {
+			_, err := r.resolveExpr(expr.defaults[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.declare(param)
+		r.parameters[param] = true
+	}
+	if expr.rest != nil {
+		r.declare(expr.rest)
+		r.parameters[expr.rest] = true
+	}
+	return nil, r.Resolve(expr.body)
+}
+
+func (r *Resolver) visitGetExpr(expr *Get[any]) (any, error) {
+	return r.resolveExpr(expr.object)
+}
+
+func (r *Resolver) visitGroupingExpr(expr *Grouping[any]) (any, error) {
+	return r.resolveExpr(expr.expression)
+}
+
+func (r *Resolver) visitLiteralExpr(expr *Literal[any]) (any, error) {
+	return nil, nil
+}
+
+func (r *Resolver) visitLogicalExpr(expr *Logical[any]) (any, error) {
+	_, err := r.resolveExpr(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	return r.resolveExpr(expr.right)
+}
+
+func (r *Resolver) visitUnaryExpr(expr *Unary[any]) (any, error) {
+	return r.resolveExpr(expr.right)
+}
+
+func (r *Resolver) visitVariableExpr(expr *Variable[any]) (any, error) {
+	r.reads[expr.name] = true
+	r.resolveName(expr.name)
+	return nil, nil
+}
+
+func (r *Resolver) resolveExpr(expr Expr[any]) (any, error) {
+	return expr.accept(r)
+}
+
+// Link the name to the declaration of the closest scope
+func (r *Resolver) resolveName(name *Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		declaration, ok := r.scopes[i][name.lexeme]
+		if ok {
+			r.references[name] = declaration
+			return
+		}
+	}
+	if _, ok := r.natives[name.lexeme]; !ok {
+		r.unresolved = append(r.unresolved, name)
+	}
+}
+
+func (r *Resolver) declare(name *Token) {
+	for i := len(r.scopes) - 2; i >= 0; i-- {
+		if outer, ok := r.scopes[i][name.lexeme]; ok {
+			r.shadows[name] = outer
+			break
+		}
+	}
+	r.scopes[len(r.scopes)-1][name.lexeme] = name
+	r.declarations = append(r.declarations, name)
+	r.depths[name] = len(r.scopes) - 1
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]*Token))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
@@ -10,10 +10,16 @@ import (
 type Scanner struct {
 	source string
 	tokens []*Token
+	// Add the comments to the tokens instead of ignoring them
+	keepComments bool
 
 	start   int
 	current int
 	line    int
+	// Position of the start of the current line in the source
+	lineStart int
+	// Position of the start of the current token in its line
+	startColumn int
 }
 
 func NewScanner(source string, tokenCapacity int) *Scanner {
@@ -22,6 +28,14 @@ func NewScanner(source string, tokenCapacity int) *Scanner {
 	}
 }
 
+// Create a scanner keeping the comments as COMMENT tokens. The tokens are not
+// meant to be parsed but to be used by the tools working on the source code.
+func NewScannerWithComments(source string, tokenCapacity int) *Scanner {
+	scanner := NewScanner(source, tokenCapacity)
+	scanner.keepComments = true
+	return scanner
+}
+
 func (s *Scanner) scanTokens() ([]*Token, error) {
 	errs := make([]*SyntaxError, 0, 10)
 	for {
@@ -29,6 +43,7 @@ func (s *Scanner) scanTokens() ([]*Token, error) {
 			break
 		}
 		s.start = s.current
+		s.startColumn = s.start - s.lineStart
 		err := s.scanToken()
 		if err != nil {
 			var syntaxErr *SyntaxError
@@ -37,7 +52,7 @@ func (s *Scanner) scanTokens() ([]*Token, error) {
 			}
 		}
 	}
-	s.tokens = append(s.tokens, NewToken(EOF, "", nil, s.line))
+	s.tokens = append(s.tokens, NewToken(EOF, "", nil, s.line, s.current-s.lineStart))
 	if len(errs) > 0 {
 		return s.tokens, NewSyntaxErrors(errs...)
 	}
@@ -60,7 +75,13 @@ func (s *Scanner) scanToken() error {
 	case ',':
 		s.addToken(COMMA)
 	case '.':
-		s.addToken(DOT)
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.next()
+			s.next()
+			s.addToken(ELLIPSIS)
+		} else {
+			s.addToken(DOT)
+		}
 	case '-':
 		s.addToken(MINUS)
 	case '+':
@@ -69,6 +90,8 @@ func (s *Scanner) scanToken() error {
 		s.addToken(SEMICOLON)
 	case '*':
 		s.addToken(STAR)
+	case '%':
+		s.addToken(PERCENT)
 	case '!':
 		if s.nextMatch('=') {
 			s.addToken(BANG_EQUAL)
@@ -78,6 +101,8 @@ func (s *Scanner) scanToken() error {
 	case '=':
 		if s.nextMatch('=') {
 			s.addToken(EQUAL_EQUAL)
+		} else if s.nextMatch('>') {
+			s.addToken(ARROW)
 		} else {
 			s.addToken(EQUAL)
 		}
@@ -105,6 +130,9 @@ func (s *Scanner) scanToken() error {
 					s.next()
 				}
 			}
+			if s.keepComments {
+				s.addToken(COMMENT)
+			}
 		} else {
 			s.addToken(SLASH)
 		}
@@ -114,6 +142,7 @@ func (s *Scanner) scanToken() error {
 	// New line
 	case '\n':
 		s.line += 1
+		s.lineStart = s.current
 	// String
 	case '"':
 		if s.nextString() {
@@ -185,10 +214,11 @@ func (s *Scanner) nextString() bool {
 			}
 			break
 		}
+		s.next()
 		if c == '\n' {
 			s.line += 1
+			s.lineStart = s.current
 		}
-		s.next()
 	}
 	return true
 }
@@ -205,7 +235,7 @@ func (s *Scanner) nextMatch(expected byte) bool {
 
 func (s *Scanner) addTokenWithLiteral(tokenType TokenType, literal any) {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, NewToken(tokenType, text, literal, s.line))
+	s.tokens = append(s.tokens, NewToken(tokenType, text, literal, s.line, s.startColumn))
 }
 
 func (s *Scanner) addToken(tokenType TokenType) {
@@ -254,8 +284,7 @@ func (s *Scanner) isAtEnd() bool {
 
 // Check if the current character has reach the end of the line or the end of the source
 func (s *Scanner) isAtEndLine() bool {
-	length := len(s.source)
-	if s.current >= length || s.current+1 >= length || s.current+2 >= length {
+	if s.isAtEnd() {
 		return true
 	}
 	return s.peek() == '\r' && s.peekNext() == '\n' || s.peek() == '\n'
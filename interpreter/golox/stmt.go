@@ -33,13 +33,15 @@ func (e *Expression[T]) accept(visitor StmtVisitor[T]) (T, error){
 }
 
 type If[T any] struct {
+    keyword *Token
     condition Expr[T]
     thenBranch Stmt[T]
     elseBranch Stmt[T]
 }
 
-func NewIf[T any](condition Expr[T], thenBranch Stmt[T], elseBranch Stmt[T]) *If[T] {
+func NewIf[T any](keyword *Token, condition Expr[T], thenBranch Stmt[T], elseBranch Stmt[T]) *If[T] {
     return &If[T]{
+        keyword: keyword,
         condition: condition,
         thenBranch: thenBranch,
         elseBranch: elseBranch,
@@ -50,12 +52,34 @@ func (e *If[T]) accept(visitor StmtVisitor[T]) (T, error){
     return visitor.visitIfStmt(e)
 }
 
+type Import[T any] struct {
+    keyword *Token
+    path *Token
+    alias *Token
+    names []*Token
+}
+
+func NewImport[T any](keyword *Token, path *Token, alias *Token, names []*Token) *Import[T] {
+    return &Import[T]{
+        keyword: keyword,
+        path: path,
+        alias: alias,
+        names: names,
+    }
+}
+
+func (e *Import[T]) accept(visitor StmtVisitor[T]) (T, error){
+    return visitor.visitImportStmt(e)
+}
+
 type Print[T any] struct {
+    keyword *Token
     expression Expr[T]
 }
 
-func NewPrint[T any](expression Expr[T]) *Print[T] {
+func NewPrint[T any](keyword *Token, expression Expr[T]) *Print[T] {
     return &Print[T]{
+        keyword: keyword,
         expression: expression,
     }
 }
@@ -64,6 +88,60 @@ func (e *Print[T]) accept(visitor StmtVisitor[T]) (T, error){
     return visitor.visitPrintStmt(e)
 }
 
+type Return[T any] struct {
+    keyword *Token
+    value Expr[T]
+}
+
+func NewReturn[T any](keyword *Token, value Expr[T]) *Return[T] {
+    return &Return[T]{
+        keyword: keyword,
+        value: value,
+    }
+}
+
+func (e *Return[T]) accept(visitor StmtVisitor[T]) (T, error){
+    return visitor.visitReturnStmt(e)
+}
+
+type Throw[T any] struct {
+    keyword *Token
+    value Expr[T]
+}
+
+func NewThrow[T any](keyword *Token, value Expr[T]) *Throw[T] {
+    return &Throw[T]{
+        keyword: keyword,
+        value: value,
+    }
+}
+
+func (e *Throw[T]) accept(visitor StmtVisitor[T]) (T, error){
+    return visitor.visitThrowStmt(e)
+}
+
+type Try[T any] struct {
+    keyword *Token
+    body Stmt[T]
+    name *Token
+    handler Stmt[T]
+    finalizer Stmt[T]
+}
+
+func NewTry[T any](keyword *Token, body Stmt[T], name *Token, handler Stmt[T], finalizer Stmt[T]) *Try[T] {
+    return &Try[T]{
+        keyword: keyword,
+        body: body,
+        name: name,
+        handler: handler,
+        finalizer: finalizer,
+    }
+}
+
+func (e *Try[T]) accept(visitor StmtVisitor[T]) (T, error){
+    return visitor.visitTryStmt(e)
+}
+
 type Var[T any] struct {
     name *Token
     initializer Expr[T]
@@ -81,12 +159,14 @@ func (e *Var[T]) accept(visitor StmtVisitor[T]) (T, error){
 }
 
 type While[T any] struct {
+    keyword *Token
     condition Expr[T]
     body Stmt[T]
 }
 
-func NewWhile[T any](condition Expr[T], body Stmt[T]) *While[T] {
+func NewWhile[T any](keyword *Token, condition Expr[T], body Stmt[T]) *While[T] {
     return &While[T]{
+        keyword: keyword,
         condition: condition,
         body: body,
     }
@@ -100,7 +180,11 @@ type StmtVisitor[T any] interface {
     visitBlockStmt(stmt *Block[T]) (T, error)
     visitExpressionStmt(stmt *Expression[T]) (T, error)
     visitIfStmt(stmt *If[T]) (T, error)
+    visitImportStmt(stmt *Import[T]) (T, error)
     visitPrintStmt(stmt *Print[T]) (T, error)
+    visitReturnStmt(stmt *Return[T]) (T, error)
+    visitThrowStmt(stmt *Throw[T]) (T, error)
+    visitTryStmt(stmt *Try[T]) (T, error)
     visitVarStmt(stmt *Var[T]) (T, error)
     visitWhileStmt(stmt *While[T]) (T, error)
 }
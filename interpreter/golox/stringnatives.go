@@ -0,0 +1,256 @@
+package golox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Natives working on strings. The positions in the strings are counted in
+// characters (Unicode code points), not in bytes.
+var stringNatives = []*NativeFunction{
+	NewNativeFunction("len", 1, nativeLen),
+	NewNativeFunctionWithArity("substr", Arity{min: 2, max: 3}, nativeSubstr),
+	NewNativeFunction("indexOf", 2, nativeIndexOf),
+	NewNativeFunction("split", 2, nativeSplit),
+	NewNativeFunctionWithArity("join", Arity{min: 1, max: 2}, nativeJoin),
+	NewNativeFunction("trim", 1, nativeTrim),
+	NewNativeFunction("upper", 1, nativeUpper),
+	NewNativeFunction("lower", 1, nativeLower),
+	NewNativeFunction("replace", 3, nativeReplace),
+	NewNativeFunction("startsWith", 2, nativeStartsWith),
+	NewNativeFunction("endsWith", 2, nativeEndsWith),
+	NewNativeFunction("repeat", 2, nativeRepeat),
+	NewNativeFunction("chr", 1, nativeChr),
+	NewNativeFunction("ord", 1, nativeOrd),
+}
+
+// Get the number of characters of a string or the number of elements of a list
+func nativeLen(interp *Interpreter, args []any) (any, error) {
+	switch value := args[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	case *LoxList:
+		return float64(len(value.elements)), nil
+	}
+	return nil, argumentError("len", "value", "a string or a list", args[0])
+}
+
+// Get the characters of a string from start included to end excluded, up to
+// the end of the string without end
+func nativeSubstr(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("substr", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := integerArgument("substr", "start", args[1])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(str)
+	end := len(runes)
+	if len(args) > 2 {
+		end, err = integerArgument("substr", "end", args[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || start > len(runes) {
+		return nil, fmt.Errorf("substr: the argument 'start' is out of range: %d", start)
+	}
+	if end < start || end > len(runes) {
+		return nil, fmt.Errorf("substr: the argument 'end' is out of range: %d", end)
+	}
+	return string(runes[start:end]), nil
+}
+
+// Get the position of the first occurrence of a substring, -1 if the string
+// does not contain the substring
+func nativeIndexOf(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("indexOf", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	substr, err := stringArgument("indexOf", "substring", args[1])
+	if err != nil {
+		return nil, err
+	}
+	index := strings.Index(str, substr)
+	if index < 0 {
+		return -1.0, nil
+	}
+	return float64(utf8.RuneCountInString(str[:index])), nil
+}
+
+// Split a string around a separator, an empty separator splits the string in
+// characters
+func nativeSplit(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("split", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	separator, err := stringArgument("split", "separator", args[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(str, separator)
+	err = interp.checkSize(0, len(parts))
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, len(parts))
+	for i, part := range parts {
+		elements[i] = part
+	}
+	return NewLoxList(elements), nil
+}
+
+// Join the elements of a list with a separator, empty by default. The elements
+// that are not strings are converted as by print.
+func nativeJoin(interp *Interpreter, args []any) (any, error) {
+	list, err := listArgument("join", "list", args[0])
+	if err != nil {
+		return nil, err
+	}
+	separator := ""
+	if len(args) > 1 {
+		separator, err = stringArgument("join", "separator", args[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	parts := make([]string, len(list.elements))
+	size := len(separator) * max(len(parts)-1, 0)
+	for i, element := range list.elements {
+		if str, ok := element.(string); ok {
+			parts[i] = str
+		} else {
+			parts[i] = interp.stringify(element)
+		}
+		size += len(parts[i])
+	}
+	err = interp.checkSize(0, size)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// Remove the white spaces at the start and at the end of a string
+func nativeTrim(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("trim", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(str), nil
+}
+
+func nativeUpper(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("upper", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(str), nil
+}
+
+func nativeLower(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("lower", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(str), nil
+}
+
+// Replace all the occurrences of a substring
+func nativeReplace(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("replace", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	old, err := stringArgument("replace", "old", args[1])
+	if err != nil {
+		return nil, err
+	}
+	new, err := stringArgument("replace", "new", args[2])
+	if err != nil {
+		return nil, err
+	}
+	count := strings.Count(str, old)
+	err = interp.checkSize(0, len(str)+count*(len(new)-len(old)))
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(str, old, new), nil
+}
+
+func nativeStartsWith(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("startsWith", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := stringArgument("startsWith", "prefix", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(str, prefix), nil
+}
+
+func nativeEndsWith(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("endsWith", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := stringArgument("endsWith", "suffix", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(str, suffix), nil
+}
+
+// Concatenate count copies of a string
+func nativeRepeat(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("repeat", "string", args[0])
+	if err != nil {
+		return nil, err
+	}
+	count, err := integerArgument("repeat", "count", args[1])
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("repeat: the argument 'count' must not be negative: %d", count)
+	}
+	if len(str) > 0 && count > (1<<31)/len(str) {
+		return nil, fmt.Errorf("repeat: the argument 'count' is too large: %d", count)
+	}
+	err = interp.checkSize(0, len(str)*count)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Repeat(str, count), nil
+}
+
+// Get the character of a Unicode code point
+func nativeChr(interp *Interpreter, args []any) (any, error) {
+	code, err := integerArgument("chr", "code", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return nil, fmt.Errorf("chr: the argument 'code' is not a valid code point: %d", code)
+	}
+	return string(rune(code)), nil
+}
+
+// Get the Unicode code point of a character
+func nativeOrd(interp *Interpreter, args []any) (any, error) {
+	str, err := stringArgument("ord", "character", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(str) != 1 {
+		return nil, fmt.Errorf("ord: the argument 'character' must be a single character, got %q", str)
+	}
+	code, _ := utf8.DecodeRuneInString(str)
+	return float64(code), nil
+}
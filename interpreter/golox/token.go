@@ -1,20 +1,31 @@
 package golox
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Token struct {
 	tokenType TokenType
 	lexeme    string
 	literal   any
 	line      int
+	// Position of the first character of the token in its line
+	column int
 }
 
-func NewToken(tokenType TokenType, lexeme string, literal any, line int) *Token {
+func NewToken(tokenType TokenType, lexeme string, literal any, line int, column int) *Token {
 	return &Token{
-		tokenType: tokenType, lexeme: lexeme, literal: literal, line: line,
+		tokenType: tokenType, lexeme: lexeme, literal: literal, line: line, column: column,
 	}
 }
 
+// Line of the first character of the token. It differs from the line of the
+// token only for the strings written on several lines.
+func (t *Token) startLine() int {
+	return t.line - strings.Count(t.lexeme, "\n")
+}
+
 func (t *Token) ToString() string {
 	if t.literal != nil {
 		return fmt.Sprintf("%s %q %s", t.tokenType, t.lexeme, t.literal)
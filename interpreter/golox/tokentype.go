@@ -15,6 +15,7 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
 	// One or two character tokens
 	BANG
 	BANG_EQUAL
@@ -24,48 +25,66 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ARROW
+	ELLIPSIS
 	// Literals
 	IDENTIFIER
 	STRING
 	NUMBER
 	// Keywords
 	AND
+	AS
+	CATCH
 	CLASS
 	ELSE
 	FALSE
+	FINALLY
+	FROM
 	FUN
 	FOR
 	IF
+	IMPORT
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
+	// Trivia
+	COMMENT
 	// EOF
 	EOF
 )
 
 var Keywords = map[string]TokenType{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"for":    FOR,
-	"fun":    FUN,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"var":    VAR,
-	"while":  WHILE,
+	"and":     AND,
+	"as":      AS,
+	"catch":   CATCH,
+	"class":   CLASS,
+	"else":    ELSE,
+	"false":   FALSE,
+	"finally": FINALLY,
+	"for":     FOR,
+	"from":    FROM,
+	"fun":     FUN,
+	"if":      IF,
+	"import":  IMPORT,
+	"nil":     NIL,
+	"or":      OR,
+	"print":   PRINT,
+	"return":  RETURN,
+	"super":   SUPER,
+	"this":    THIS,
+	"throw":   THROW,
+	"true":    TRUE,
+	"try":     TRY,
+	"var":     VAR,
+	"while":   WHILE,
 }
 
 var tokenTypeName = map[TokenType]string{
@@ -81,6 +100,7 @@ var tokenTypeName = map[TokenType]string{
 	SEMICOLON:   ";",
 	SLASH:       "/",
 	STAR:        "*",
+	PERCENT:     "%",
 	// One or two character tokens
 	BANG:          "!",
 	BANG_EQUAL:    "!=",
@@ -90,27 +110,38 @@ var tokenTypeName = map[TokenType]string{
 	GREATER_EQUAL: ">=",
 	LESS:          "<",
 	LESS_EQUAL:    "<=",
+	ARROW:         "=>",
+	ELLIPSIS:      "...",
 	// Literals
 	IDENTIFIER: "IDENTIFIER",
 	STRING:     "STRING",
 	NUMBER:     "NUMBER",
 	// Keywords
-	AND:    "AND",
-	CLASS:  "CLASS",
-	ELSE:   "ELSE",
-	FALSE:  "FALSE",
-	FUN:    "FUN",
-	FOR:    "FOR",
-	IF:     "IF",
-	NIL:    "NIL",
-	OR:     "OR",
-	PRINT:  "PRINT",
-	RETURN: "RETURN",
-	SUPER:  "SUPER",
-	THIS:   "THIS",
-	TRUE:   "TRUE",
-	VAR:    "VAR",
-	WHILE:  "WHILE",
+	AND:     "AND",
+	AS:      "AS",
+	CATCH:   "CATCH",
+	CLASS:   "CLASS",
+	ELSE:    "ELSE",
+	FALSE:   "FALSE",
+	FINALLY: "FINALLY",
+	FROM:    "FROM",
+	FUN:     "FUN",
+	FOR:     "FOR",
+	IF:      "IF",
+	IMPORT:  "IMPORT",
+	NIL:     "NIL",
+	OR:      "OR",
+	PRINT:   "PRINT",
+	RETURN:  "RETURN",
+	SUPER:   "SUPER",
+	THIS:    "THIS",
+	THROW:   "THROW",
+	TRUE:    "TRUE",
+	TRY:     "TRY",
+	VAR:     "VAR",
+	WHILE:   "WHILE",
+	// Trivia
+	COMMENT: "COMMENT",
 	// EOF
 	EOF: "EOF",
 }
@@ -0,0 +1,62 @@
+package golox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Natives converting the values and giving their types
+var typeNatives = []*NativeFunction{
+	NewNativeFunction("num", 1, nativeNum),
+	NewNativeFunction("str", 1, nativeStr),
+	NewNativeFunction("bool", 1, nativeBool),
+	NewNativeFunction("type", 1, nativeType),
+	NewNativeFunction("isCallable", 1, nativeIsCallable),
+	NewNativeFunction("equals", 2, nativeEquals),
+}
+
+// Convert a string or a boolean to a number
+func nativeNum(interp *Interpreter, args []any) (any, error) {
+	switch value := args[0].(type) {
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("num: the argument 'value' is not a number: %q", value)
+		}
+		return number, nil
+	}
+	return nil, argumentError("num", "value", "a number, a string or a bool", args[0])
+}
+
+// Convert a value to the string written by print
+func nativeStr(interp *Interpreter, args []any) (any, error) {
+	return interp.stringify(args[0]), nil
+}
+
+// Convert a value to a boolean, only nil and false are false
+func nativeBool(interp *Interpreter, args []any) (any, error) {
+	return interp.isTruthy(args[0]), nil
+}
+
+func nativeType(interp *Interpreter, args []any) (any, error) {
+	return typeName(args[0]), nil
+}
+
+func nativeIsCallable(interp *Interpreter, args []any) (any, error) {
+	_, ok := args[0].(GoLoxCallable)
+	return ok, nil
+}
+
+// Check if two values are equal as by ==, except that the lists are equal if
+// their elements are equal
+func nativeEquals(interp *Interpreter, args []any) (any, error) {
+	return isDeepEqual(args[0], args[1]), nil
+}
@@ -0,0 +1,67 @@
+package golox
+
+import "testing"
+
+func TestTypeNatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		output string
+		// Error of the script, no error if empty
+		err string
+	}{
+		{
+			name: "num",
+			source: "print num(\" 42 \") + 1;\nprint num(\"-1.5e2\");\nprint num(true);\nprint num(false);\n" +
+				"print num(3);\nprint num(\"inf\");",
+			output: "43.000000\n-150.000000\n1.000000\n0.000000\n3.000000\n+Inf\n",
+		},
+		{
+			name:   "str",
+			source: "print str(1) + \"!\";\nprint str(nil);\nprint str(true);\nprint str(range(2));\nprint str(\"a\");\nprint str(clock);",
+			output: "1.000000!\nnil\ntrue\n[0.000000, 1.000000]\na\n<native function>\n",
+		},
+		{
+			name:   "bool",
+			source: "print bool(nil);\nprint bool(false);\nprint bool(0);\nprint bool(\"\");",
+			output: "false\nfalse\ntrue\ntrue\n",
+		},
+		{
+			name: "type",
+			source: "print type(1);\nprint type(\"a\");\nprint type(true);\nprint type(nil);\nprint type(clock);\n" +
+				"print type(fun () {});\nprint type(range(1));\ntry { throw \"x\"; } catch (e) { print type(e); }",
+			output: "number\nstring\nbool\nnil\nfunction\nfunction\nlist\nerror\n",
+		},
+		{
+			name:   "isCallable",
+			source: "print isCallable(clock);\nprint isCallable(fun () {});\nprint isCallable(\"clock\");\nprint isCallable(nil);",
+			output: "true\ntrue\nfalse\nfalse\n",
+		},
+		{
+			name:   "num of an unparsable string",
+			source: "print 1;\nprint num(\"abc\");",
+			output: "1.000000\n",
+			err:    "[line 2] RUNTIME ERROR: num: the argument 'value' is not a number: \"abc\"",
+		},
+		{
+			name:   "num of an empty string",
+			source: "print num(\"\");",
+			err:    "[line 1] RUNTIME ERROR: num: the argument 'value' is not a number: \"\"",
+		},
+		{
+			name:   "num of nil",
+			source: "print num(nil);",
+			err:    "[line 1] RUNTIME ERROR: num: the argument 'value' must be a number, a string or a bool, got nil",
+		},
+		{
+			name:   "num of a list",
+			source: "print num(range(1));",
+			err:    "[line 1] RUNTIME ERROR: num: the argument 'value' must be a number, a string or a bool, got list",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkTestScript(t, test.source, test.output, test.err)
+		})
+	}
+}
@@ -1,19 +1,82 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"golox/golox"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			runFmt(os.Args[2:])
+			return
+		case "dap":
+			golox.NewGoLox().RunDebugAdapter()
+			return
+		case "debug":
+			if len(os.Args) != 3 {
+				log.Fatal("Usage: golox debug script")
+			}
+			golox.NewGoLox().DebugFile(os.Args[2])
+			return
+		case "lint":
+			if len(os.Args) == 2 {
+				log.Fatal("Usage: golox lint script...")
+			}
+			golox.NewGoLox().LintFiles(os.Args[2:])
+			return
+		case "lsp":
+			golox.NewGoLox().RunLanguageServer()
+			return
+		}
+	}
+	optimize := flag.Bool("O", false, "optimize the AST before interpreting it")
+	profile := flag.String("profile", "", "profile the script and write the pprof profile in the given `file`")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: golox [-O] [--profile file] [--coverage file] [script [arguments...]]")
+		flag.PrintDefaults()
+	}
+	coverage := flag.String("coverage", "", "track the coverage of the script and write it in the LCOV format in the given `file`")
+	flag.Parse()
 	// Run GoLox interpreter
 	goLox := golox.NewGoLox()
-	if len(os.Args) > 2 {
-		log.Fatal("Usage: golox [script]")
-	} else if len(os.Args) == 2 {
-		goLox.RunFile(os.Args[1])
+	if *optimize {
+		goLox.EnableOptimizer()
+	}
+	if *profile != "" {
+		goLox.EnableProfiler(*profile)
+	}
+	if *coverage != "" {
+		if *profile != "" {
+			log.Fatal("--profile and --coverage cannot be combined")
+		}
+		goLox.EnableCoverage(*coverage)
+	}
+	if flag.NArg() >= 1 {
+		goLox.SetArguments(flag.Args()[1:])
+		// Stop the script cleanly when the process is interrupted
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		goLox.RunFileContext(ctx, flag.Arg(0))
 	} else {
 		goLox.RunPrompt()
 	}
 }
+
+// Format the scripts given as arguments
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "exit with an error if a script is not formatted instead of formatting it")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: golox fmt [--check] script...")
+	}
+	golox.NewGoLox().FormatFiles(flags.Args(), *check)
+}
@@ -59,7 +59,12 @@ func defineType(file *os.File, interfaceName string, structName string, fields s
 		} else if fieldType == "Expr" || fieldType == "Stmt" {
 			fieldType = fieldType + "[T]"
 		} else if len(fieldType) > 5 && fieldType[0:5] == "List<" && fieldType[len(fieldType)-1] == '>' {
-			fieldType = "[]" + fieldType[5:len(fieldType)-1] + "[T]"
+			elementType := fieldType[5 : len(fieldType)-1]
+			if elementType == "Expr" || elementType == "Stmt" {
+				fieldType = "[]" + elementType + "[T]"
+			} else {
+				fieldType = "[]*" + elementType
+			}
 		} else {
 			fieldType = "*" + fieldType
 		}
@@ -94,6 +99,8 @@ func main() {
 		"Assign   : Token name, Expr value",
 		"Binary   : Expr left, Token operator, Expr right",
 		"Call     : Expr callee, Token paren, List<Expr> arguments",
+		"Function : Token keyword, List<Token> params, List<Expr> defaults, Token rest, List<Stmt> body",
+		"Get      : Expr object, Token name",
 		"Grouping : Expr expression",
 		"Literal  : Object value",
 		"Logical  : Expr left, Token operator, Expr right",
@@ -103,10 +110,14 @@ func main() {
 	defineAst(outputDir, "Stmt", []string{
 		"Block      : List<Stmt> statements",
 		"Expression : Expr expression",
-		"If         : Expr condition, Stmt thenBranch, Stmt elseBranch",
-		"Print      : Expr expression",
+		"If         : Token keyword, Expr condition, Stmt thenBranch, Stmt elseBranch",
+		"Import     : Token keyword, Token path, Token alias, List<Token> names",
+		"Print      : Token keyword, Expr expression",
+		"Return     : Token keyword, Expr value",
+		"Throw      : Token keyword, Expr value",
+		"Try        : Token keyword, Stmt body, Token name, Stmt handler, Stmt finalizer",
 		"Var        : Token name, Expr initializer",
-		"While      : Expr condition, Stmt body",
+		"While      : Token keyword, Expr condition, Stmt body",
 		// "For        : Stmt initializer, Expr condition, Expr increment, Stmt body",
 	})
 }